@@ -2,6 +2,7 @@ package lemin
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -10,8 +11,6 @@ import (
 
 var FileSlice = []string{}
 
-//var numberOfAnts int
-
 func ReadFile(filename string) ([]string, error) {
 	fileAddress := "examples/" + filename
 	file, err := os.Open(fileAddress)
@@ -38,28 +37,37 @@ func ReadFile(filename string) ([]string, error) {
 }
 
 func Makefarm() {
-	//var err error
-	numberOfAnts, err := strconv.Atoi(FileSlice[0])
+	if len(FileSlice) < 3 {
+		fmt.Println("file is too short to hold an ant number and a start room")
+		return
+	}
+	_, err := strconv.Atoi(FileSlice[0])
 	if err != nil {
-		fmt.Println("fuck")
+		fmt.Println(err)
 	}
 	if FileSlice[1] == "##start" {
-		start := CreateRoom(FileSlice[2])
+		start, err := CreateRoom(FileSlice[2])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
 
 		fmt.Println("start room is :", start)
 
 	}
 }
-func CreateRoom(roomStr string) room {
-	startStr := strings.Split(roomStr, " ")
+func CreateRoom(roomStr string) (room, error) {
+	startStr := strings.Fields(roomStr)
+	if len(startStr) != 3 {
+		return room{}, errors.New("room must be given as \"name x y\": " + roomStr)
+	}
 	x, err1 := strconv.Atoi(startStr[1])
 	if err1 != nil {
-		fmt.Println(err1)
+		return room{}, err1
 	}
 	y, err2 := strconv.Atoi(startStr[2])
 	if err2 != nil {
-		fmt.Println(err1)
+		return room{}, err2
 	}
-	room := SetRoom(startStr[0], x, y)
-	return room
+	return SetRoom(startStr[0], x, y), nil
 }
@@ -0,0 +1,35 @@
+package lemin
+
+import "testing"
+
+// Inputs which used to make Makefarm and CreateRoom panic (index out of range)
+func TestMakefarm(t *testing.T) {
+	inputs := [][]string{{}, {"3"}, {"3", "##start"}, {"3", "##start", "a"}, {"3", "##start", "a 1"},
+		{"3", "##start", ""}, {"x", "##start", "a 1 2"}, {"3", "##start", "a  1  2"}}
+	for _, input := range inputs {
+		FileSlice = input
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("\nfunction Makefarm panicking for input %q"+
+						"\ngot: %v", input, r)
+				}
+			}()
+			Makefarm()
+		}()
+	}
+	FileSlice = []string{}
+}
+
+func TestCreateRoom(t *testing.T) {
+	inputs := []string{"a 1 2", " a  1\t2 ", "", "a", "a 1", "a 1 2 3", "a x 2", "a 1 y"}
+	correctRoom := []room{{"a", 1, 2}, {"a", 1, 2}, {}, {}, {}, {}, {}, {}}
+	correctErr := []bool{false, false, true, true, true, true, true, true}
+	for i, input := range inputs {
+		got, err := CreateRoom(input)
+		if got != correctRoom[i] || (err != nil) != correctErr[i] {
+			t.Errorf("\nfunction CreateRoom not returning expected room for input %q"+
+				"\ngot: %v, %v \nexpected: %v, error: %v", input, got, err, correctRoom[i], correctErr[i])
+		}
+	}
+}
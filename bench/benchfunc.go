@@ -0,0 +1,202 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"io"
+	"lem-in/routing"
+	"lem-in/sys"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+/*
+StageParse is the stage of reading and parsing the file, measured before the stages of routing.Solver.Run.
+*/
+const StageParse = "parse"
+
+/*
+Stages lists the stages measured for every map, in order.
+*/
+var Stages = []string{StageParse, routing.StageSearch, routing.StageSelect, routing.StageSimulate}
+
+/*
+Measurement is the wall time and number of heap allocations (and allocated bytes) of one stage.
+*/
+type Measurement struct {
+	Time   time.Duration
+	Allocs uint64
+	Bytes  uint64
+}
+
+/*
+Result holds the measurements of one map (file): its size, the number of turns of the solution and the
+Measurement of each stage (by stage name), or the error which stopped the map from being solved.
+*/
+type Result struct {
+	File   string
+	Rooms  int
+	Ants   int
+	Turns  int
+	Stages map[string]Measurement
+	Err    error
+}
+
+/*
+meter measures consecutive stages: each call to "start" ends the current stage (if any) and starts
+the named one, and "stop" ends the current stage.
+*/
+type meter struct {
+	stages  map[string]Measurement
+	current string
+	begin   time.Time
+	memory  runtime.MemStats
+}
+
+func (m *meter) start(stage string) {
+	m.stop()
+	m.current = stage
+	runtime.ReadMemStats(&m.memory)
+	m.begin = time.Now()
+}
+
+func (m *meter) stop() {
+	if m.current == "" {
+		return
+	}
+	elapsed := time.Since(m.begin)
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+	m.stages[m.current] = Measurement{
+		Time:   elapsed,
+		Allocs: memory.Mallocs - m.memory.Mallocs,
+		Bytes:  memory.TotalAlloc - m.memory.TotalAlloc,
+	}
+	m.current = ""
+}
+
+/*
+Measure reads, parses and solves the farm in the named file with the given routing algorithm (without
+printing the moves), and returns the Result with the measurements of every stage. Errors in reading,
+parsing or solving the farm are recorded in the Err of the Result.
+*/
+func Measure(fileName, algorithm string) Result {
+	result := Result{File: filepath.Base(fileName), Stages: make(map[string]Measurement, len(Stages))}
+	m := &meter{stages: result.Stages}
+
+	m.start(StageParse)
+	file, err := os.Open(fileName)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	farm, err := sys.SetupFromReader(file)
+	file.Close()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Rooms, result.Ants = len(farm.Network), farm.TotalAntNbr
+
+	solver := routing.NewSolver(farm)
+	solver.Algorithm = algorithm
+	solver.EchoInput = false
+	solver.Output = io.Discard
+	solver.OnStage = m.start
+	err = solver.Run(context.Background())
+	m.stop()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Turns = solver.TurnCount
+	return result
+}
+
+/*
+MeasureDir runs Measure on every .txt file in the directory, in alphabetical order. A non-nil error is
+returned if the directory can not be read or contains no .txt files.
+*/
+func MeasureDir(dir, algorithm string) ([]Result, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	} else if len(files) == 0 {
+		return nil, errors.New("\nERROR: no .txt files found in directory " + dir)
+	}
+	sort.Strings(files)
+	results := make([]Result, 0, len(files))
+	for _, file := range files {
+		results = append(results, Measure(file, algorithm))
+	}
+	return results, nil
+}
+
+/*
+formatMeasurement formats a Measurement as a table cell, e.g. "1.2ms 340 allocs".
+*/
+func formatMeasurement(measurement Measurement, found bool) string {
+	if !found {
+		return "-"
+	}
+	return measurement.Time.Round(time.Microsecond).String() + " " +
+		strconv.FormatUint(measurement.Allocs, 10) + " allocs"
+}
+
+/*
+WriteTable writes the Results as an aligned table to the io.Writer: one row per map, with its size,
+number of turns, and the wall time and allocations of every stage. Maps which could not be solved are
+listed with the first line of their error.
+*/
+func WriteTable(writer io.Writer, results []Result) error {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	header := "FILE\tROOMS\tANTS\tTURNS"
+	for _, stage := range Stages {
+		header += "\t" + stage
+	}
+	header += "\ttotal\n"
+	if _, err := io.WriteString(table, header); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		row := result.File + "\t" + strconv.Itoa(result.Rooms) + "\t" + strconv.Itoa(result.Ants) + "\t"
+		if result.Err != nil {
+			row += "error: " + firstLine(result.Err.Error()) + "\n"
+		} else {
+			row += strconv.Itoa(result.Turns)
+			total := Measurement{}
+			for _, stage := range Stages {
+				measurement, found := result.Stages[stage]
+				row += "\t" + formatMeasurement(measurement, found)
+				total.Time += measurement.Time
+				total.Allocs += measurement.Allocs
+			}
+			row += "\t" + formatMeasurement(total, true) + "\n"
+		}
+		if _, err := io.WriteString(table, row); err != nil {
+			return err
+		}
+	}
+	return table.Flush()
+}
+
+/*
+firstLine returns the first non-empty line of an error message (errors begin with a line break).
+*/
+func firstLine(message string) string {
+	for len(message) > 0 && message[0] == '\n' {
+		message = message[1:]
+	}
+	for i, char := range message {
+		if char == '\n' {
+			return message[:i]
+		}
+	}
+	return message
+}
@@ -0,0 +1,51 @@
+package bench
+
+import (
+	"bytes"
+	"lem-in/generate"
+	"lem-in/routing"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMeasureDir(t *testing.T) {
+	// Establish test directory with one valid and one invalid map
+	dir := t.TempDir()
+	fileContents, err := generate.Generate(generate.DefaultOptions())
+	if err != nil {
+		t.Fatalf("\nerror in generating farm \ngot: %v", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "a.txt"), []byte(strings.Join(fileContents, "\n")), 0644); err != nil {
+		t.Fatalf("\nerror in writing test file \ngot: %v", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "b.txt"), []byte("0\n"), 0644); err != nil {
+		t.Fatalf("\nerror in writing test file \ngot: %v", err)
+	}
+
+	results, err := MeasureDir(dir, routing.AlgorithmFlow)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("\nfunction MeasureDir not returning a result per file \ngot: %v \nerror: %v", results, err)
+	}
+	if results[0].Err != nil || results[0].Turns == 0 || results[0].Rooms != 20 || len(results[0].Stages) != len(Stages) {
+		t.Errorf("\nfunction MeasureDir not measuring every stage of the valid map \ngot: %+v", results[0])
+	}
+	if results[1].Err == nil {
+		t.Errorf("\nfunction MeasureDir not recording the error of the invalid map \ngot: %+v", results[1])
+	}
+
+	var output bytes.Buffer
+	if err = WriteTable(&output, results); err != nil {
+		t.Fatalf("\nfunction WriteTable returning unexpected error \ngot: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "a.txt") || !strings.Contains(lines[1], "allocs") ||
+		!strings.Contains(lines[2], "error: ERROR: invalid data format") {
+		t.Errorf("\nfunction WriteTable not writing the expected table \ngot: \n%v", output.String())
+	}
+
+	if _, err = MeasureDir(t.TempDir(), routing.AlgorithmFlow); err == nil {
+		t.Errorf("\nfunction MeasureDir not returning error for directory without maps")
+	}
+}
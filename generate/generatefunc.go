@@ -0,0 +1,465 @@
+package generate
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+/*
+Connectivity states whether the generated farm must have a route between its start and end rooms.
+*/
+type Connectivity int
+
+const (
+	ConnectAny        Connectivity = iota // Start and end rooms may or may not be connected
+	ConnectGuaranteed                     // At least one route between start and end rooms
+	ConnectForbidden                      // No route between start and end rooms (an invalid farm for solving)
+)
+
+/*
+Topology families of the generated farms.
+*/
+const (
+	TopologyRandom     = "random"     // Random geometric graph: rooms linked to the rooms near them
+	TopologyGrid       = "grid"       // Rooms on a grid, linked to their horizontal and vertical neighbours
+	TopologyTree       = "tree"       // Random tree rooted in the start room
+	TopologyBottleneck = "bottleneck" // Layers of rooms, with a single room in the middle layer
+	TopologyCorridor   = "corridor"   // Long corridor from start to end room, with dead-end decoy branches
+)
+
+/*
+Options configures a generated farm. Rooms is the total number of rooms (including the start and end
+rooms), Density the number of extra random links per room added on top of the topology, Ants the number
+of ants and CoordRange the range of the room coordinates (0 to CoordRange - 1). The same Seed always
+generates the same farm.
+*/
+type Options struct {
+	Rooms        int
+	Density      float64
+	Ants         int
+	CoordRange   int
+	Connectivity Connectivity
+	Topology     string
+	Seed         int64
+}
+
+/*
+DefaultOptions returns the Options used by the "generate" command when none are given: a random
+geometric farm of 20 rooms and 10 ants, with a guaranteed route from start to end room.
+*/
+func DefaultOptions() Options {
+	return Options{
+		Rooms:        20,
+		Density:      0.5,
+		Ants:         10,
+		CoordRange:   100,
+		Connectivity: ConnectGuaranteed,
+		Topology:     TopologyRandom,
+		Seed:         1,
+	}
+}
+
+/*
+farm is the farm under construction: the coordinates of each room (room 0 is the start room, the last
+room the end room) and its set of links, each stored once as [lower index, higher index].
+*/
+type farm struct {
+	coords [][2]int
+	links  map[[2]int]bool
+	rng    *rand.Rand
+}
+
+/*
+link adds a link between rooms a and b, unless they are the same room or already linked.
+*/
+func (f *farm) link(a, b int) {
+	if a == b {
+		return
+	} else if a > b {
+		a, b = b, a
+	}
+	f.links[[2]int{a, b}] = true
+}
+
+/*
+randomCoords assigns unique random coordinates (within the coordinate range) to every room.
+*/
+func (f *farm) randomCoords(rooms, coordRange int) {
+	used := make(map[[2]int]bool, rooms)
+	f.coords = make([][2]int, 0, rooms)
+	for len(f.coords) < rooms {
+		coords := [2]int{f.rng.Intn(coordRange), f.rng.Intn(coordRange)}
+		if !used[coords] {
+			used[coords] = true
+			f.coords = append(f.coords, coords)
+		}
+	}
+}
+
+/*
+addRandomLinks adds "count" links between random pairs of rooms for which "allowed" returns true.
+Pairs are drawn a limited number of times, so that fewer links may be added for restrictive rules.
+*/
+func (f *farm) addRandomLinks(count int, allowed func(a, b int) bool) {
+	rooms := len(f.coords)
+	for attempt := 0; count > 0 && attempt < 20*(count+rooms); attempt++ {
+		a, b := f.rng.Intn(rooms), f.rng.Intn(rooms)
+		if a != b && allowed(a, b) && !f.links[[2]int{minInt(a, b), maxInt(a, b)}] {
+			f.link(a, b)
+			count--
+		}
+	}
+}
+
+/*
+extraLinks returns the number of extra random links for the Density of the Options.
+*/
+func extraLinks(options Options) int {
+	return int(options.Density * float64(options.Rooms))
+}
+
+/*
+buildRandom links every pair of rooms closer than a radius chosen such that each room has about
+2 + 2 * Density links on average (random geometric graph).
+*/
+func (f *farm) buildRandom(options Options) {
+	f.randomCoords(options.Rooms, options.CoordRange)
+	area := float64(options.CoordRange) * float64(options.CoordRange)
+	radius := math.Sqrt((2 + 2*options.Density) * area / (math.Pi * float64(options.Rooms)))
+	for a := range f.coords {
+		for b := a + 1; b < len(f.coords); b++ {
+			dx, dy := float64(f.coords[a][0]-f.coords[b][0]), float64(f.coords[a][1]-f.coords[b][1])
+			if math.Sqrt(dx*dx+dy*dy) <= radius {
+				f.link(a, b)
+			}
+		}
+	}
+}
+
+/*
+buildGrid lays the rooms out on a grid (row by row, spaced out over the coordinate range) and links
+every room to its horizontal and vertical neighbours. The start room is the top-left room and the end
+room the last room of the grid. Extra random links are added anywhere.
+*/
+func (f *farm) buildGrid(options Options) {
+	columns := int(math.Ceil(math.Sqrt(float64(options.Rooms))))
+	rows := (options.Rooms + columns - 1) / columns
+	spacing := maxInt(1, (options.CoordRange-1)/maxInt(columns-1, rows-1, 1))
+	f.coords = make([][2]int, options.Rooms)
+	for i := range f.coords {
+		f.coords[i] = [2]int{(i % columns) * spacing, (i / columns) * spacing}
+		if i%columns > 0 {
+			f.link(i-1, i)
+		}
+		if i >= columns {
+			f.link(i-columns, i)
+		}
+	}
+	f.addRandomLinks(extraLinks(options), func(a, b int) bool { return true })
+}
+
+/*
+buildTree attaches every room to a random earlier room, building a random tree rooted in the start
+room. The end room is attached to the deepest room of the tree. Extra random links are added anywhere.
+*/
+func (f *farm) buildTree(options Options) {
+	f.randomCoords(options.Rooms, options.CoordRange)
+	end := options.Rooms - 1
+	depth := make([]int, options.Rooms)
+	deepest := 0
+	for i := 1; i < end; i++ {
+		parent := f.rng.Intn(i)
+		f.link(parent, i)
+		depth[i] = depth[parent] + 1
+		if depth[i] > depth[deepest] {
+			deepest = i
+		}
+	}
+	f.link(deepest, end)
+	f.addRandomLinks(extraLinks(options), func(a, b int) bool { return true })
+}
+
+/*
+buildBottleneck splits the intermediate rooms into layers, with a single room in the middle layer.
+The start room is linked to the first layer, every layer to the next one (each room to at least one
+room of the next layer) and the last layer to the end room, so that all routes pass through the
+bottleneck. Extra random links are only added within a layer or between neighbouring layers.
+*/
+func (f *farm) buildBottleneck(options Options) {
+	f.randomCoords(options.Rooms, options.CoordRange)
+	intermediates := options.Rooms - 2
+	layerCount := 1
+	if intermediates >= 3 {
+		layerCount = maxInt(3, int(math.Sqrt(float64(intermediates))))
+		if layerCount%2 == 0 {
+			layerCount++
+		}
+	}
+	// layer[i] = layer of room i (start room = -1, end room = layerCount)
+	layer := make([]int, options.Rooms)
+	layer[0], layer[options.Rooms-1] = -1, layerCount
+	middle := layerCount / 2
+	for i := 1; i <= intermediates; i++ {
+		if i == 1 || layerCount == 1 {
+			layer[i] = middle
+		} else {
+			// Any layer but the middle one
+			layer[i] = f.rng.Intn(layerCount - 1)
+			if layer[i] >= middle {
+				layer[i]++
+			}
+		}
+	}
+
+	rooms := make([][]int, layerCount+2) // rooms[layer + 1] = rooms of layer
+	for i, l := range layer {
+		rooms[l+1] = append(rooms[l+1], i)
+	}
+	for l := 0; l < len(rooms)-1; l++ {
+		if len(rooms[l]) == 0 {
+			continue
+		}
+		// Next non-empty layer
+		next := l + 1
+		for next < len(rooms)-1 && len(rooms[next]) == 0 {
+			next++
+		}
+		for _, a := range rooms[l] {
+			f.link(a, rooms[next][f.rng.Intn(len(rooms[next]))])
+		}
+		for _, b := range rooms[next] {
+			f.link(rooms[l][f.rng.Intn(len(rooms[l]))], b)
+		}
+	}
+	f.addRandomLinks(extraLinks(options), func(a, b int) bool {
+		return layer[a] != middle && layer[b] != middle && layer[a] >= 0 && layer[b] >= 0 &&
+			layer[a] < layerCount && layer[b] < layerCount && absInt(layer[a]-layer[b]) <= 1 &&
+			(layer[a]-middle)*(layer[b]-middle) > 0
+	})
+}
+
+/*
+buildCorridor links about half of the intermediate rooms into a single long corridor from the start
+room to the end room. The remaining (decoy) rooms form dead-end branches hanging off the corridor. Extra
+random links are only added between decoy rooms, or between a decoy room and the corridor, so that the
+corridor stays the only route.
+*/
+func (f *farm) buildCorridor(options Options) {
+	f.randomCoords(options.Rooms, options.CoordRange)
+	end := options.Rooms - 1
+	corridor := (options.Rooms - 2 + 1) / 2
+	// Corridor: start, 1, 2 ... corridor, end
+	for i := 1; i <= corridor; i++ {
+		f.link(i-1, i)
+	}
+	f.link(corridor, end)
+
+	// Decoy branches: each decoy room hangs off a corridor room or an earlier decoy room
+	decoy := make([]bool, options.Rooms)
+	branch := make([]int, options.Rooms) // Corridor room each decoy branch hangs off
+	for i := corridor + 1; i < end; i++ {
+		decoy[i] = true
+		if i > corridor+1 && f.rng.Intn(2) == 0 {
+			parent := corridor + 1 + f.rng.Intn(i-corridor-1)
+			branch[i] = branch[parent]
+			f.link(parent, i)
+		} else {
+			branch[i] = 1 + f.rng.Intn(corridor)
+			f.link(branch[i], i)
+		}
+	}
+	f.addRandomLinks(extraLinks(options), func(a, b int) bool {
+		return decoy[a] && decoy[b] && branch[a] == branch[b]
+	})
+}
+
+/*
+reachable returns, for every room, whether it can be reached from the given room over the links
+of the farm.
+*/
+func (f *farm) reachable(from int) []bool {
+	adjacent := make([][]int, len(f.coords))
+	for pair := range f.links {
+		adjacent[pair[0]] = append(adjacent[pair[0]], pair[1])
+		adjacent[pair[1]] = append(adjacent[pair[1]], pair[0])
+	}
+	visited := make([]bool, len(f.coords))
+	visited[from] = true
+	queue := []int{from}
+	for len(queue) > 0 {
+		room := queue[0]
+		queue = queue[1:]
+		for _, next := range adjacent[room] {
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return visited
+}
+
+/*
+connect links the start and end rooms if required by the Connectivity of the Options: for
+ConnectGuaranteed, rooms reachable from the start room are linked to rooms reaching the end room until
+there is a route; for ConnectForbidden, all links of the end room are removed if it can be reached from
+the start room. A non-nil error is returned if no link can remain without a route.
+*/
+func (f *farm) connect(options Options) error {
+	start, end := 0, len(f.coords)-1
+	if options.Connectivity == ConnectGuaranteed {
+		for fromStart := f.reachable(start); !fromStart[end]; fromStart = f.reachable(start) {
+			toEnd := f.reachable(end)
+			var a, b []int
+			for i := range f.coords {
+				if fromStart[i] {
+					a = append(a, i)
+				} else if toEnd[i] {
+					b = append(b, i)
+				}
+			}
+			f.link(a[f.rng.Intn(len(a))], b[f.rng.Intn(len(b))])
+		}
+	} else if options.Connectivity == ConnectForbidden {
+		if fromStart := f.reachable(start); fromStart[end] {
+			// Cut the end room loose, keeping the links of all other rooms
+			for pair := range f.links {
+				if pair[0] == end || pair[1] == end {
+					delete(f.links, pair)
+				}
+			}
+		}
+		if len(f.links) == 0 {
+			if len(f.coords) < 3 {
+				return errors.New("\nERROR: invalid options, a farm without a route between start and end " +
+					"rooms requires at least 3 rooms")
+			}
+			f.link(0, 1)
+		}
+	}
+	return nil
+}
+
+/*
+checkOptions returns a non-nil error if the Options can not produce a valid farm.
+*/
+func checkOptions(options Options) error {
+	if options.Rooms < 2 {
+		return errors.New("\nERROR: invalid options, at least 2 rooms are required")
+	} else if options.Ants < 1 {
+		return errors.New("\nERROR: invalid options, the number of ants must be a positive integer")
+	} else if options.Density < 0 {
+		return errors.New("\nERROR: invalid options, the link density may not be negative")
+	} else if options.CoordRange < 1 || options.CoordRange*options.CoordRange < options.Rooms {
+		return errors.New("\nERROR: invalid options, the coordinate range is too small for " +
+			strconv.Itoa(options.Rooms) + " rooms with unique coordinates")
+	} else if options.Connectivity < ConnectAny || options.Connectivity > ConnectForbidden {
+		return errors.New("\nERROR: invalid options, unknown connectivity")
+	}
+	return nil
+}
+
+/*
+Generate builds a farm for the Options and returns it as the lines of an input file: the number of
+ants, the rooms (with ##start / ##end labels) and the links. Room names are alphanumeric ("start",
+"end" and "r1", "r2" ... for intermediate rooms), coordinates are unique and there are no self-links
+or duplicate links, so that the output is accepted by sys.Setup unchanged. A non-nil error is returned
+for invalid Options.
+*/
+func Generate(options Options) ([]string, error) {
+	if err := checkOptions(options); err != nil {
+		return nil, err
+	}
+	f := &farm{links: make(map[[2]int]bool), rng: rand.New(rand.NewSource(options.Seed))}
+	if options.Topology == TopologyRandom {
+		f.buildRandom(options)
+	} else if options.Topology == TopologyGrid {
+		f.buildGrid(options)
+	} else if options.Topology == TopologyTree {
+		f.buildTree(options)
+	} else if options.Topology == TopologyBottleneck {
+		f.buildBottleneck(options)
+	} else if options.Topology == TopologyCorridor {
+		f.buildCorridor(options)
+	} else {
+		return nil, errors.New("\nERROR: invalid options, unknown topology \" " + options.Topology + " \"")
+	}
+	if err := f.connect(options); err != nil {
+		return nil, err
+	}
+	if len(f.links) == 0 {
+		// At least one link is required by sys.Setup
+		f.link(0, len(f.coords)-1)
+	}
+	return f.lines(options.Ants), nil
+}
+
+/*
+roomName returns the name of the room with the given index.
+*/
+func (f *farm) roomName(i int) string {
+	if i == 0 {
+		return "start"
+	} else if i == len(f.coords)-1 {
+		return "end"
+	}
+	return "r" + strconv.Itoa(i)
+}
+
+/*
+lines writes the farm as the lines of an input file, with the links in a fixed (sorted) order.
+*/
+func (f *farm) lines(ants int) []string {
+	output := make([]string, 0, 3+len(f.coords)+len(f.links))
+	output = append(output, strconv.Itoa(ants))
+	for i, coords := range f.coords {
+		if i == 0 {
+			output = append(output, "##start")
+		} else if i == len(f.coords)-1 {
+			output = append(output, "##end")
+		}
+		output = append(output, f.roomName(i)+" "+strconv.Itoa(coords[0])+" "+strconv.Itoa(coords[1]))
+	}
+
+	pairs := make([][2]int, 0, len(f.links))
+	for pair := range f.links {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i][0] < pairs[j][0] || (pairs[i][0] == pairs[j][0] && pairs[i][1] < pairs[j][1])
+	})
+	for _, pair := range pairs {
+		output = append(output, f.roomName(pair[0])+"-"+f.roomName(pair[1]))
+	}
+	return output
+}
+
+/*
+minInt, maxInt and absInt are integer helper functions.
+*/
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(values ...int) int {
+	max := values[0]
+	for _, value := range values[1:] {
+		if value > max {
+			max = value
+		}
+	}
+	return max
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
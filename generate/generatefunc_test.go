@@ -0,0 +1,112 @@
+package generate
+
+import (
+	"lem-in/sys"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/*
+connected returns whether the end room of the input sys.Farm can be reached from its start room.
+*/
+func connected(farm *sys.Farm) bool {
+	visited := map[*sys.Room]bool{farm.Start: true}
+	queue := []*sys.Room{farm.Start}
+	for len(queue) > 0 {
+		room := queue[0]
+		queue = queue[1:]
+		for _, link := range room.Links {
+			if !visited[link] {
+				visited[link] = true
+				queue = append(queue, link)
+			}
+		}
+	}
+	return visited[farm.End]
+}
+
+func TestGenerate(t *testing.T) {
+	topologies := []string{TopologyRandom, TopologyGrid, TopologyTree, TopologyBottleneck, TopologyCorridor}
+	sizes := []int{2, 3, 10, 57}
+
+	for _, topology := range topologies {
+		for _, rooms := range sizes {
+			for seed := int64(1); seed <= 5; seed++ {
+				for _, connectivity := range []Connectivity{ConnectGuaranteed, ConnectForbidden} {
+					if connectivity == ConnectForbidden && rooms < 3 {
+						continue
+					}
+					options := Options{Rooms: rooms, Density: 0.7, Ants: 5, CoordRange: 20,
+						Connectivity: connectivity, Topology: topology, Seed: seed}
+					lines, err := Generate(options)
+					if err != nil {
+						t.Fatalf("\nfunction Generate returning unexpected error for options %+v \ngot: %v", options, err)
+					}
+					farm, err := sys.Parse(lines)
+					if err != nil {
+						t.Fatalf("\nfunction Generate returning a farm rejected by sys.Parse for options %+v"+
+							"\ngot: %v \nfarm: %v", options, err, strings.Join(lines, "\n"))
+					}
+					if len(farm.Network) != rooms || farm.TotalAntNbr != 5 {
+						t.Errorf("\nfunction Generate not returning the requested farm size for options %+v"+
+							"\ngot: %v rooms, %v ants", options, len(farm.Network), farm.TotalAntNbr)
+					}
+					if connected(farm) != (connectivity == ConnectGuaranteed) {
+						t.Errorf("\nfunction Generate not returning the requested connectivity for options %+v"+
+							"\nfarm: %v", options, strings.Join(lines, "\n"))
+					}
+					for _, room := range farm.Network {
+						if room.Coords[0] < 0 || room.Coords[0] >= 20 || room.Coords[1] < 0 || room.Coords[1] >= 20 {
+							t.Errorf("\nfunction Generate returning coordinates out of range for options %+v"+
+								"\ngot: %v", options, room.Coords)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Same seed, same farm
+	options := DefaultOptions()
+	first, _ := Generate(options)
+	second, _ := Generate(options)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("\nfunction Generate not returning the same farm for the same seed")
+	}
+
+	// Invalid options
+	invalid := []Options{{Rooms: 1, Ants: 1, CoordRange: 10, Topology: TopologyRandom},
+		{Rooms: 10, Ants: 0, CoordRange: 10, Topology: TopologyRandom},
+		{Rooms: 10, Ants: 1, CoordRange: 3, Topology: TopologyRandom},
+		{Rooms: 10, Ants: 1, CoordRange: 10, Topology: "unknown"},
+		{Rooms: 2, Ants: 1, CoordRange: 10, Topology: TopologyRandom, Connectivity: ConnectForbidden}}
+	for _, options := range invalid {
+		if _, err := Generate(options); err == nil {
+			t.Errorf("\nfunction Generate not returning error for invalid options %+v", options)
+		}
+	}
+}
+
+func TestBottleneck(t *testing.T) {
+	// All routes must pass through the single middle room (r1)
+	options := Options{Rooms: 40, Density: 2, Ants: 5, CoordRange: 50, Topology: TopologyBottleneck, Seed: 3}
+	lines, err := Generate(options)
+	if err != nil {
+		t.Fatalf("\nfunction Generate returning unexpected error \ngot: %v", err)
+	}
+	var withoutMiddle []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "r1 ") && !strings.HasPrefix(line, "r1-") && !strings.HasSuffix(line, "-r1") {
+			withoutMiddle = append(withoutMiddle, line)
+		}
+	}
+	farm, err := sys.Parse(withoutMiddle)
+	if err != nil {
+		t.Fatalf("\nerror in parsing farm without middle room \ngot: %v", err)
+	}
+	if connected(farm) {
+		t.Errorf("\nfunction Generate returning a bottleneck farm with a route avoiding the middle room"+
+			"\nfarm: %v", strings.Join(lines, "\n"))
+	}
+}
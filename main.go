@@ -1,30 +1,319 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"lem-in/bench"
+	"lem-in/generate"
+	"lem-in/repl"
 	"lem-in/routing"
 	"lem-in/sys"
+	"lem-in/verify"
+	"lem-in/visual"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 )
 
+var algorithm = flag.String("algorithm", routing.AlgorithmDFS, "route-finding algorithm, either \""+
+	routing.AlgorithmDFS+"\" (depth-first search of up to "+strconv.Itoa(routing.DefaultMaxPaths)+" paths) or \""+
+	routing.AlgorithmFlow+"\" (max-flow, for large farms)")
+
+var rules = flag.String("rules", sys.RulesRooms, "rule set for moving ants, either \""+sys.RulesRooms+
+	"\" (one ant per room) or \""+sys.RulesTunnels+"\" (one ant per tunnel per turn, rooms may be shared)")
+
+var format = flag.String("format", "text", "output format, either \"text\" (input file followed by the moves) "+
+	"or \"json\" (farm, routes, ant grouping, rating and moves as a single JSON document)")
+
+var check = flag.Bool("check", false, "only check the input file, printing every error found with its line and column")
+
+var visualize = flag.Bool("visualize", false, "animate the ants turn by turn in the terminal (step, pause, rewind)")
+
+var export = flag.String("export", "", "write a rendering of the farm and chosen routes instead of the moves, "+
+	"either \"svg\" (static image) or \"html\" (page with an animation of the ants)")
+
+var timeout = flag.Duration("timeout", 0, "maximum time spent searching for routes (e.g. \"10s\"), after which "+
+	"the best routes found so far are used (0 = no limit)")
+
+var workers = flag.Int("workers", runtime.NumCPU(), "number of route combinations rated in parallel by the "+
+	"\""+routing.AlgorithmDFS+"\" algorithm (the chosen routes are the same for any number)")
+
+var summary = flag.Bool("summary", false, "only print the number of turns, instead of the moves of every turn")
+
+var example = flag.String("example", "", "solve (or check) the built-in example file with the given name "+
+	"(e.g. \"example00\") instead of an input file")
+
+var quiet = flag.Bool("quiet", false, "do not print the input file before the moves (e.g. for benchmarking)")
+
 /*
 See README.md in main repository.
 */
 func main() {
-	args := os.Args
-	if len(args) == 2 {
+	flag.Parse()
+	args := flag.Args()
+	if len(args) > 0 && args[0] == "verify" {
+		runVerify(args[1:])
+	} else if len(args) > 0 && args[0] == "generate" {
+		runGenerate(args[1:])
+	} else if len(args) > 0 && args[0] == "bench" {
+		runBench(args[1:])
+	} else if len(args) > 0 && args[0] == "repl" {
+		runRepl(args[1:])
+	} else if *example != "" && len(args) > 0 {
+		log.Fatal("\nERROR: invalid data format \n" + "please enter either an input file or an \" -example \", not both")
+	} else if *check && len(args) <= 1 {
+		runCheck(args)
+	} else if len(args) <= 1 {
+		checkAlgorithm()
+		var farm *sys.Farm
 		var errLemIn error
-		errLemIn = sys.Setup(args[1])
+		if *example != "" {
+			farm, errLemIn = sys.SetupExample(*example)
+		} else if len(args) == 0 || args[0] == "-" {
+			// Read farm from standard input (e.g. piped from a generator)
+			farm, errLemIn = sys.SetupFromReader(os.Stdin)
+		} else {
+			farm, errLemIn = sys.Setup(args[0])
+		}
 		if errLemIn != nil {
 			log.Fatal(errLemIn)
 		}
-		errLemIn = routing.Run()
+		solver := routing.NewSolver(farm)
+		solver.Algorithm = *algorithm
+		solver.Rules = *rules
+		solver.EchoInput = !*quiet
+		solver.Workers = *workers
+		solver.SummaryOnly = *summary
+		if *format == "json" || *visualize || *export != "" {
+			solver.EchoInput = false
+			solver.Output = io.Discard
+			solver.RecordTurns = true
+		}
+		if *format != "text" && *format != "json" {
+			log.Fatal("\nERROR: invalid data format \n" + "unknown output format \" " + *format +
+				" \", please enter either \" text \" or \" json \"")
+		} else if *export != "" && *export != "svg" && *export != "html" {
+			log.Fatal("\nERROR: invalid data format \n" + "unknown export format \" " + *export +
+				" \", please enter either \" svg \" or \" html \"")
+		} else if *rules != sys.RulesRooms && *rules != sys.RulesTunnels {
+			log.Fatal("\nERROR: invalid data format \n" + "unknown rule set \" " + *rules +
+				" \", please enter either \" " + sys.RulesRooms + " \" or \" " + sys.RulesTunnels + " \"")
+		}
+		var ctx context.Context
+		var cancel context.CancelFunc
+		if *timeout > 0 {
+			ctx, cancel = context.WithTimeout(context.Background(), *timeout)
+		} else {
+			ctx, cancel = context.WithCancel(context.Background())
+		}
+		defer cancel()
+		errLemIn = solver.Run(ctx)
+		expired := ctx.Err() != nil
+		if errLemIn != nil {
+			log.Fatal(errLemIn)
+		}
+		if solver.Interrupted && expired {
+			fmt.Fprintln(os.Stderr, "WARNING: route search stopped after "+timeout.String()+
+				", the solution found so far may be sub-optimal")
+		} else if solver.Interrupted {
+			fmt.Fprintln(os.Stderr, "WARNING: route search stopped after "+strconv.Itoa(solver.MaxPaths)+
+				" paths, the solution may be sub-optimal (try \" -algorithm "+routing.AlgorithmFlow+" \")")
+		}
+		if *visualize {
+			// Commands are read from the terminal, even if the farm was piped to standard input
+			errLemIn = visual.NewPlayer(farm, solver.Turns).Play(terminalInput(), os.Stdout)
+		} else if *export == "svg" {
+			errLemIn = visual.WriteSVG(os.Stdout, solver)
+		} else if *export == "html" {
+			errLemIn = visual.WriteHTML(os.Stdout, solver)
+		} else if *format == "json" {
+			errLemIn = solver.WriteJSON(os.Stdout)
+		}
 		if errLemIn != nil {
 			log.Fatal(errLemIn)
 		}
 	} else {
-		log.Fatal("\nERROR: invalid data format \n" + "please enter only one argument, " +
-			"corresponding to the name of the input file")
+		log.Fatal("\nERROR: invalid data format \n" + "please enter at most one argument, " +
+			"corresponding to the name of the input file (or \" - \" / none to read from standard input)")
+	}
+}
+
+/*
+checkAlgorithm exits with an error message if the "-algorithm" flag names an unknown route-finding algorithm,
+rather than letting the routing package report an internal malfunction.
+*/
+func checkAlgorithm() {
+	if *algorithm != routing.AlgorithmDFS && *algorithm != routing.AlgorithmFlow {
+		log.Fatal("\nERROR: invalid data format \n" + "unknown routing algorithm \" " + *algorithm +
+			" \", please enter either \" " + routing.AlgorithmDFS + " \" or \" " + routing.AlgorithmFlow + " \"")
+	}
+}
+
+/*
+runVerify implements the "verify" subcommand: "lem-in verify <farm file> <move log>". The farm is read (and
+validated) as usual, after which the move log (or standard input if given as " - ") is replayed against it,
+under the rule set given by the "-rules" flag. The number of turns is printed, followed by every illegal move
+found. The program exits with a non-zero status if the move log is invalid.
+*/
+func runVerify(args []string) {
+	if len(args) != 2 {
+		log.Fatal("\nERROR: invalid data format \n" + "usage: lem-in [-rules rooms|tunnels] verify <farm file> <move log>")
+	}
+	farm, errLemIn := sys.Setup(args[0])
+	if errLemIn != nil {
+		log.Fatal(errLemIn)
+	}
+	moveLog, errLemIn := verify.ReadLog(args[1])
+	if errLemIn != nil {
+		log.Fatal(errLemIn)
+	}
+	report, errLemIn := verify.CheckRules(farm, moveLog, *rules)
+	if errLemIn != nil {
+		log.Fatal(errLemIn)
+	}
+
+	fmt.Println("turns: " + strconv.Itoa(report.Turns))
+	for _, violation := range report.Violations {
+		fmt.Println(violation)
+	}
+	if len(report.Violations) > 0 {
+		fmt.Println("INVALID: " + strconv.Itoa(len(report.Violations)) + " violation(s) found")
+		os.Exit(1)
+	}
+	fmt.Println("OK")
+}
+
+/*
+runCheck implements the lint mode: "lem-in -check <farm file>" (or standard input if no file / " - " is given,
+or the built-in example given with " -example "). Every error in the input is printed along with its line
+and column, rather than only the first one. The program exits with a non-zero status if any errors are found.
+*/
+func runCheck(args []string) {
+	var errs []*sys.ParseError
+	var errLemIn error
+	if *example != "" {
+		errs, errLemIn = sys.LintExample(*example)
+	} else if len(args) == 0 || args[0] == "-" {
+		errs, errLemIn = sys.LintReader(os.Stdin)
+	} else {
+		errs, errLemIn = sys.LintFile(args[0])
+	}
+	if errLemIn != nil {
+		log.Fatal(errLemIn)
+	}
+
+	for _, err := range errs {
+		fmt.Println(strings.TrimPrefix(err.Error(), "\n"))
+	}
+	if len(errs) > 0 {
+		fmt.Println("INVALID: " + strconv.Itoa(len(errs)) + " error(s) found")
+		os.Exit(1)
+	}
+	fmt.Println("OK")
+}
+
+/*
+terminalInput returns the reader for interactive commands: standard input, unless it has already been
+used to read the farm, in which case the terminal itself (/dev/tty) is opened where available.
+*/
+func terminalInput() io.Reader {
+	if *example == "" && (len(flag.Args()) == 0 || flag.Args()[0] == "-") {
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			return tty
+		}
+	}
+	return os.Stdin
+}
+
+/*
+runGenerate implements the "generate" subcommand: "lem-in generate [options]". A random farm is
+generated for the options (see "lem-in generate -h") and printed to standard output, in the input file
+format, so that it can be saved or piped straight back into lem-in.
+*/
+func runGenerate(args []string) {
+	defaults := generate.DefaultOptions()
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	rooms := flags.Int("rooms", defaults.Rooms, "total number of rooms, including start and end rooms")
+	density := flags.Float64("density", defaults.Density, "number of extra random links per room")
+	ants := flags.Int("ants", defaults.Ants, "number of ants")
+	coords := flags.Int("coords", defaults.CoordRange, "range of the room coordinates (0 to coords - 1)")
+	connect := flags.String("connect", "yes", "route between start and end rooms: \"yes\" (guaranteed), "+
+		"\"no\" (forbidden) or \"any\"")
+	topology := flags.String("topology", defaults.Topology, "farm topology: \""+generate.TopologyRandom+"\", \""+
+		generate.TopologyGrid+"\", \""+generate.TopologyTree+"\", \""+generate.TopologyBottleneck+"\" or \""+
+		generate.TopologyCorridor+"\"")
+	seed := flags.Int64("seed", defaults.Seed, "random seed (the same seed always generates the same farm)")
+	flags.Parse(args)
+
+	options := generate.Options{Rooms: *rooms, Density: *density, Ants: *ants, CoordRange: *coords,
+		Topology: *topology, Seed: *seed}
+	if *connect == "yes" {
+		options.Connectivity = generate.ConnectGuaranteed
+	} else if *connect == "no" {
+		options.Connectivity = generate.ConnectForbidden
+	} else if *connect == "any" {
+		options.Connectivity = generate.ConnectAny
+	} else {
+		log.Fatal("\nERROR: invalid options \n" + "unknown connectivity \" " + *connect +
+			" \", please enter either \" yes \", \" no \" or \" any \"")
+	}
+
+	lines, errLemIn := generate.Generate(options)
+	if errLemIn != nil {
+		log.Fatal(errLemIn)
+	}
+	fmt.Println(strings.Join(lines, "\n"))
+}
+
+/*
+runBench implements the "bench" subcommand: "lem-in bench [directory]". Every .txt file in the directory
+(./sys/examples by default) is parsed and solved with the algorithm given by the "-algorithm" flag, and a
+table of the number of turns, wall time and allocations of every stage is printed.
+*/
+func runBench(args []string) {
+	dir := filepath.Join("sys", "examples")
+	if len(args) == 1 {
+		dir = args[0]
+	} else if len(args) > 1 {
+		log.Fatal("\nERROR: invalid data format \n" + "usage: lem-in [-algorithm dfs|flow] bench [directory]")
+	}
+	checkAlgorithm()
+	results, errLemIn := bench.MeasureDir(dir, *algorithm)
+	if errLemIn != nil {
+		log.Fatal(errLemIn)
+	}
+	errLemIn = bench.WriteTable(os.Stdout, results)
+	if errLemIn != nil {
+		log.Fatal(errLemIn)
+	}
+}
+
+/*
+runRepl implements the "repl" subcommand: "lem-in repl <farm file>". The farm is read (and validated) as
+usual, after which commands are read from standard input to explore and edit it in memory, and to run the
+steps of the solution one at a time (see repl.Session), with the algorithm and rule set given by the
+"-algorithm" and "-rules" flags.
+*/
+func runRepl(args []string) {
+	if len(args) != 1 {
+		log.Fatal("\nERROR: invalid data format \n" +
+			"usage: lem-in [-algorithm dfs|flow] [-rules rooms|tunnels] repl <farm file>")
+	}
+	checkAlgorithm()
+	farm, errLemIn := sys.Setup(args[0])
+	if errLemIn != nil {
+		log.Fatal(errLemIn)
+	}
+	session := repl.NewSession(farm)
+	session.Algorithm, session.Rules = *algorithm, *rules
+	errLemIn = session.Run(os.Stdin, os.Stdout)
+	if errLemIn != nil {
+		log.Fatal(errLemIn)
 	}
 }
 
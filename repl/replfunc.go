@@ -0,0 +1,449 @@
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"lem-in/routing"
+	"lem-in/sys"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+Session is an interactive shell for exploring a sys.Farm: its rooms and links can be listed and edited in
+memory (without re-reading the input file), and the steps of routing.Solver.Run can be run one at a time
+(paths, conflicts, rating, route selection and moving the ants turn by turn). The paths found by the last
+"paths" command (listed by index), and the Solver of the last "solve" command, are kept until the Farm is
+edited.
+*/
+type Session struct {
+	Farm      *sys.Farm
+	Algorithm string // Route-finding algorithm used by "solve": routing.AlgorithmDFS or routing.AlgorithmFlow
+	Rules     string // Rule set for moving ants: sys.RulesRooms or sys.RulesTunnels
+	paths     [][]*sys.Room
+	solver    *routing.Solver
+}
+
+/*
+DefaultPaths is the number of paths listed by the "paths" command if none is given.
+*/
+const DefaultPaths = 10
+
+/*
+help lists the commands of a Session, as printed by the "help" command.
+*/
+const help = `commands:
+  rooms            list the rooms (name, coordinates, class, capacity and ants)
+  links ROOM       list the links of a room
+  paths [K]        list the K shortest paths found by the depth-first search (default 10)
+  conflicts        list the paths conflicting with each path
+  rate I,J,...     rate the combination of paths given by their indices
+  solve            find and select the routes, and share the ants between them
+  step [N]         move the ants by one turn (or N turns), solving first if needed
+  addlink A-B [W]  add a link between rooms A and B (taking W turns to cross)
+  rmroom ROOM      remove a room and its links
+  ants N           set the number of ants
+  help             list the commands
+  quit             leave the session
+`
+
+/*
+NewSession returns a Session for the input sys.Farm, with the routing.AlgorithmDFS route-finding algorithm
+and the sys.RulesRooms rule set.
+*/
+func NewSession(farm *sys.Farm) *Session {
+	return &Session{Farm: farm, Algorithm: routing.AlgorithmDFS, Rules: sys.RulesRooms}
+}
+
+/*
+Run reads commands (one per line) from the io.Reader and writes the output of each (see Execute) to the
+io.Writer, with a "> " prompt before each command. Run returns when "quit" is entered or the input ends. A
+non-nil error is returned if reading or writing fails.
+*/
+func (session *Session) Run(reader io.Reader, writer io.Writer) error {
+	scanner := bufio.NewScanner(reader)
+	_, err := io.WriteString(writer, "lem-in repl: "+strconv.Itoa(len(session.Farm.Network))+" rooms, "+
+		strconv.Itoa(session.Farm.TotalAntNbr)+" ants (enter \" help \" for the list of commands)\n> ")
+	for err == nil && scanner.Scan() {
+		output, quit := session.Execute(scanner.Text())
+		if quit {
+			return nil
+		}
+		_, err = io.WriteString(writer, output+"> ")
+	}
+	if err != nil {
+		return err
+	}
+	return scanner.Err()
+}
+
+/*
+Execute runs a single command line and returns its output (ending with a new line, unless empty), along
+with true if the command ends the session. Errors (e.g. unknown rooms or commands) are returned as output,
+as they do not end the session.
+*/
+func (session *Session) Execute(line string) (string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", false
+	}
+	command, args := fields[0], fields[1:]
+	var output string
+	var err error
+
+	if command == "quit" || command == "exit" {
+		return "", true
+	} else if command == "help" {
+		output = help
+	} else if command == "rooms" && len(args) == 0 {
+		output = session.rooms()
+	} else if command == "links" && len(args) == 1 {
+		output, err = session.links(args[0])
+	} else if command == "paths" && len(args) <= 1 {
+		output, err = session.listPaths(args)
+	} else if command == "conflicts" && len(args) == 0 {
+		output, err = session.conflicts()
+	} else if command == "rate" && len(args) == 1 {
+		output, err = session.rate(args[0])
+	} else if command == "solve" && len(args) == 0 {
+		output, err = session.solve()
+	} else if command == "step" && len(args) <= 1 {
+		output, err = session.step(args)
+	} else if command == "addlink" && (len(args) == 1 || len(args) == 2) {
+		output, err = session.addLink(args)
+	} else if command == "rmroom" && len(args) == 1 {
+		output, err = session.removeRoom(args[0])
+	} else if command == "ants" && len(args) == 1 {
+		output, err = session.setAnts(args[0])
+	} else {
+		output = "unknown command \" " + line + " \", enter \" help \" for the list of commands\n"
+	}
+	if err != nil {
+		return strings.TrimPrefix(err.Error(), "\n") + "\n", false
+	}
+	return output, false
+}
+
+/*
+newSolver returns a routing.Solver for the Farm of the Session, with its algorithm and rule set, printing
+nothing.
+*/
+func (session *Session) newSolver() *routing.Solver {
+	solver := routing.NewSolver(session.Farm)
+	solver.Algorithm = session.Algorithm
+	solver.Rules = session.Rules
+	solver.EchoInput = false
+	solver.Output = io.Discard
+	return solver
+}
+
+/*
+edited discards the paths and Solver of the Session, which no longer match its edited Farm.
+*/
+func (session *Session) edited() {
+	session.paths, session.solver = nil, nil
+}
+
+/*
+findPaths returns the paths of the Session, searching for them first if no "paths" command has been run
+since the Farm was last edited.
+*/
+func (session *Session) findPaths() ([][]*sys.Room, error) {
+	if session.paths == nil {
+		paths, err := session.newSolver().Paths(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		session.paths = paths
+	}
+	return session.paths, nil
+}
+
+/*
+formatRoute returns a route as its room names joined by hyphens, followed by its number of turns.
+*/
+func formatRoute(route []*sys.Room) string {
+	names := make([]string, len(route))
+	turns := 0
+	for i, room := range route {
+		names[i] = room.Name
+		if i > 0 {
+			turns += route[i-1].Weight(room)
+		}
+	}
+	return strings.Join(names, "-") + " (" + strconv.Itoa(turns) + " turns)"
+}
+
+/*
+rooms lists every room of the Farm as "name x y class", followed by its capacity and number of ants if given.
+*/
+func (session *Session) rooms() string {
+	var output strings.Builder
+	output.WriteString("ants: " + strconv.Itoa(session.Farm.TotalAntNbr) + "\n")
+	for _, room := range session.Farm.Network {
+		output.WriteString(room.Name + " " + strconv.Itoa(room.Coords[0]) + " " + strconv.Itoa(room.Coords[1]) +
+			" " + room.Class)
+		if room.Capacity > 0 {
+			output.WriteString(" capacity " + strconv.Itoa(room.Capacity))
+		}
+		if room.Ants > 0 {
+			output.WriteString(" ants " + strconv.Itoa(room.Ants))
+		}
+		output.WriteString("\n")
+	}
+	return output.String()
+}
+
+/*
+findRoom returns the room of the Farm with the given name, along with a non-nil error if there is none.
+*/
+func (session *Session) findRoom(name string) (*sys.Room, error) {
+	for i := range session.Farm.Network {
+		if session.Farm.Network[i].Name == name {
+			return &session.Farm.Network[i], nil
+		}
+	}
+	return nil, errors.New("\nERROR: invalid data format, unknown room \" " + name + " \"")
+}
+
+/*
+links lists the links of the named room as "room-link", followed by the number of turns taken to cross the
+link if more than one.
+*/
+func (session *Session) links(name string) (string, error) {
+	room, err := session.findRoom(name)
+	if err != nil {
+		return "", err
+	}
+	var output strings.Builder
+	for _, link := range room.Links {
+		output.WriteString(room.Name + "-" + link.Name)
+		if weight := room.Weight(link); weight > 1 {
+			output.WriteString(" (" + strconv.Itoa(weight) + " turns)")
+		}
+		output.WriteString("\n")
+	}
+	if len(room.Links) == 0 {
+		output.WriteString("no links\n")
+	}
+	return output.String(), nil
+}
+
+/*
+listPaths lists the shortest paths (DefaultPaths, or the number given) by index, as used by "conflicts" and
+"rate".
+*/
+func (session *Session) listPaths(args []string) (string, error) {
+	count := DefaultPaths
+	if len(args) == 1 {
+		var errAtoi error
+		count, errAtoi = strconv.Atoi(args[0])
+		if errAtoi != nil || count < 1 {
+			return "", errors.New("\nERROR: invalid data format, number of paths must be a positive integer")
+		}
+	}
+	paths, err := session.findPaths()
+	if err != nil {
+		return "", err
+	}
+	var output strings.Builder
+	for i, path := range paths {
+		if i == count {
+			output.WriteString("(" + strconv.Itoa(len(paths)-count) + " more paths)\n")
+			break
+		}
+		output.WriteString(strconv.Itoa(i) + ": " + formatRoute(path) + "\n")
+	}
+	return output.String(), nil
+}
+
+/*
+conflicts lists the indices of the paths conflicting with each path, under the rule set of the Session.
+*/
+func (session *Session) conflicts() (string, error) {
+	paths, err := session.findPaths()
+	if err != nil {
+		return "", err
+	}
+	conflictMap, err := session.newSolver().Conflicts(paths)
+	if err != nil {
+		return "", err
+	}
+	var output strings.Builder
+	for i := range paths {
+		output.WriteString(strconv.Itoa(i) + ":")
+		if len(conflictMap[i]) == 0 {
+			output.WriteString(" none")
+		}
+		for _, j := range conflictMap[i] {
+			output.WriteString(" " + strconv.Itoa(j))
+		}
+		output.WriteString("\n")
+	}
+	return output.String(), nil
+}
+
+/*
+rate rates the combination of paths given by their comma-separated indices (e.g. "0,2,5"), listing the
+number of turns and ant moves, the ants sent down each path, and any pairs of conflicting paths.
+*/
+func (session *Session) rate(list string) (string, error) {
+	paths, err := session.findPaths()
+	if err != nil {
+		return "", err
+	}
+	var indices []int
+	for _, field := range strings.Split(list, ",") {
+		index, errAtoi := strconv.Atoi(field)
+		if errAtoi != nil || index < 0 || index >= len(paths) {
+			return "", errors.New("\nERROR: invalid data format, path index \" " + field + " \" outside of range 0.." +
+				strconv.Itoa(len(paths)-1))
+		}
+		for _, other := range indices {
+			if other == index {
+				return "", errors.New("\nERROR: invalid data format, path index \" " + field + " \" given more than once")
+			}
+		}
+		indices = append(indices, index)
+	}
+	solver := session.newSolver()
+	rating, antGrouping, err := solver.Rate(paths, indices)
+	if err != nil {
+		return "", err
+	}
+	conflictMap, err := solver.Conflicts(paths)
+	if err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	output.WriteString("turns: " + strconv.Itoa(rating[0]) + ", ant moves: " + strconv.Itoa(rating[1]) + "\n")
+	for k, index := range indices {
+		output.WriteString(strconv.Itoa(index) + ": " + strconv.Itoa(antGrouping[k]) + " ants\n")
+	}
+	sorted := append([]int{}, indices...)
+	sort.Ints(sorted)
+	for k, i := range sorted {
+		for _, j := range sorted[k+1:] {
+			for _, conflict := range conflictMap[i] {
+				if conflict == j {
+					output.WriteString("WARNING: paths " + strconv.Itoa(i) + " and " + strconv.Itoa(j) + " conflict\n")
+				}
+			}
+		}
+	}
+	return output.String(), nil
+}
+
+/*
+solve finds and selects the routes of the Farm (see routing.Solver.Prepare), listing each route with its
+ants, and the rating of the routes. The ants can then be moved with "step".
+*/
+func (session *Session) solve() (string, error) {
+	solver := session.newSolver()
+	if err := solver.Prepare(context.Background()); err != nil {
+		return "", err
+	}
+	session.solver = solver
+
+	var output strings.Builder
+	for i, route := range solver.Routes {
+		output.WriteString("route " + strconv.Itoa(i+1) + ": " + formatRoute(route) + ", " +
+			strconv.Itoa(solver.InitialGrouping[i]) + " ants\n")
+	}
+	output.WriteString("turns: " + strconv.Itoa(solver.Rating[0]) + ", ant moves: " +
+		strconv.Itoa(solver.Rating[1]) + "\n")
+	return output.String(), nil
+}
+
+/*
+step moves the ants by one turn (or the number of turns given), listing the moves of each turn, solving
+the Farm first if no "solve" command has been run since it was last edited.
+*/
+func (session *Session) step(args []string) (string, error) {
+	count := 1
+	if len(args) == 1 {
+		var errAtoi error
+		count, errAtoi = strconv.Atoi(args[0])
+		if errAtoi != nil || count < 1 {
+			return "", errors.New("\nERROR: invalid data format, number of turns must be a positive integer")
+		}
+	}
+	var output strings.Builder
+	if session.solver == nil {
+		solved, err := session.solve()
+		if err != nil {
+			return "", err
+		}
+		output.WriteString(solved)
+	}
+
+	solver := session.solver
+	for i := 0; i < count; i++ {
+		moved, err := solver.Step()
+		if err != nil {
+			return output.String(), err
+		} else if !moved {
+			break
+		}
+		moves := make([]string, len(solver.CurrentTurn))
+		for j, move := range solver.CurrentTurn {
+			moves[j] = "L" + strconv.Itoa(move.Ant) + "-" + move.To
+		}
+		output.WriteString("turn " + strconv.Itoa(solver.TurnCount) + ": " + strings.Join(moves, " ") + "\n")
+	}
+	if solver.TotalAntsFinished >= solver.Farm.TotalAntNbr {
+		output.WriteString("all ants finished after " + strconv.Itoa(solver.TurnCount) + " turns\n")
+	}
+	return output.String(), nil
+}
+
+/*
+addLink adds the link given as "A-B" to the Farm (see sys.Farm.AddLink), taking the number of turns given
+(if any) to cross.
+*/
+func (session *Session) addLink(args []string) (string, error) {
+	weight := 1
+	if len(args) == 2 {
+		var errAtoi error
+		weight, errAtoi = strconv.Atoi(args[1])
+		if errAtoi != nil || weight < 1 || weight > sys.MaxAnts {
+			return "", errors.New("\nERROR: invalid data format, tunnel weight must be an integer between 1 and " +
+				strconv.Itoa(sys.MaxAnts))
+		}
+	}
+	if err := session.Farm.AddLink(args[0], weight); err != nil {
+		return "", err
+	}
+	session.edited()
+	return "added link " + args[0] + "\n", nil
+}
+
+/*
+removeRoom removes the named room and its links from the Farm (see sys.Farm.RemoveRoom).
+*/
+func (session *Session) removeRoom(name string) (string, error) {
+	if err := session.Farm.RemoveRoom(name); err != nil {
+		return "", err
+	}
+	session.edited()
+	return "removed room " + name + "\n", nil
+}
+
+/*
+setAnts sets the number of ants of the Farm (see sys.Farm.SetAnts).
+*/
+func (session *Session) setAnts(value string) (string, error) {
+	ants, errAtoi := strconv.Atoi(value)
+	if errAtoi != nil {
+		return "", errors.New("\nERROR: invalid data format, number of ants must be a positive integer")
+	}
+	if err := session.Farm.SetAnts(ants); err != nil {
+		return "", err
+	}
+	session.edited()
+	return "ants: " + value + "\n", nil
+}
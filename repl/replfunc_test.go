@@ -0,0 +1,88 @@
+package repl
+
+import (
+	"lem-in/sys"
+	"strings"
+	"testing"
+)
+
+// Farm with two disjoint paths, s-a-e and s-b-c-e
+var testFarm = []string{"3", "##start", "s 0 0", "a 1 0", "b 1 1", "c 2 1", "##end", "e 3 0", "s-a", "a-e", "s-b",
+	"b-c", "c-e"}
+
+func newTestSession(t *testing.T) *Session {
+	farm, err := sys.Parse(testFarm)
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error"+
+			"\ngot: %v", err)
+	}
+	return NewSession(farm)
+}
+
+func TestExecute(t *testing.T) {
+	session := newTestSession(t)
+	commands := []string{"paths", "paths 1", "conflicts", "rate 1,0", "links b", "solve", "step 2", "step 5",
+		"addlink a-c", "paths", "conflicts", "rmroom a", "paths", "ants 1", "solve"}
+	correctOutput := []string{
+		"0: s-a-e (2 turns)\n1: s-b-c-e (3 turns)\n",
+		"0: s-a-e (2 turns)\n(1 more paths)\n",
+		"0: none\n1: none\n",
+		"turns: 3, ant moves: 7\n1: 1 ants\n0: 2 ants\n",
+		"b-s\nb-c\n",
+		"route 1: s-a-e (2 turns), 2 ants\nroute 2: s-b-c-e (3 turns), 1 ants\nturns: 3, ant moves: 7\n",
+		"turn 1: L1-a L2-b\nturn 2: L1-e L2-c L3-a\n",
+		"turn 3: L3-e L2-e\nall ants finished after 3 turns\n",
+		"added link a-c\n",
+		"0: s-a-e (2 turns)\n1: s-a-c-e (3 turns)\n2: s-b-c-e (3 turns)\n3: s-b-c-a-e (4 turns)\n",
+		"0: 1 3\n1: 0 2 3\n2: 1 3\n3: 0 1 2\n",
+		"removed room a\n",
+		"0: s-b-c-e (3 turns)\n",
+		"ants: 1\n",
+		"route 1: s-b-c-e (3 turns), 1 ants\nturns: 3, ant moves: 3\n",
+	}
+	for i, command := range commands {
+		output, quit := session.Execute(command)
+		if output != correctOutput[i] || quit {
+			t.Errorf("\nfunction Execute not returning expected output for command \" %v \""+
+				"\ngot: %q \nexpected: %q", command, output, correctOutput[i])
+		}
+	}
+}
+
+func TestExecuteErrors(t *testing.T) {
+	session := newTestSession(t)
+	commands := []string{"links x", "rate 0,0", "rate 0,2", "paths 0", "step x", "addlink a-x", "addlink a-c 0",
+		"rmroom s", "ants x", "foo", "rooms 1"}
+	correctPrefix := []string{"ERROR: invalid data format, unknown room", "ERROR: invalid data format, path index",
+		"ERROR: invalid data format, path index", "ERROR: invalid data format, number of paths",
+		"ERROR: invalid data format, number of turns", "ERROR: invalid data format, input contains non-existent room",
+		"ERROR: invalid data format, tunnel weight", "ERROR: invalid data format",
+		"ERROR: invalid data format, number of ants", "unknown command", "unknown command"}
+	for i, command := range commands {
+		output, quit := session.Execute(command)
+		if !strings.HasPrefix(output, correctPrefix[i]) || quit {
+			t.Errorf("\nfunction Execute not returning expected error for command \" %v \""+
+				"\ngot: %v \nexpected: %v", command, output, correctPrefix[i])
+		}
+	}
+	if len(session.Farm.Network) != 5 || len(session.Farm.Network[1].Links) != 2 {
+		t.Errorf("\nfunction Execute editing the farm for invalid commands"+
+			"\ngot: %v", session.Farm.Network)
+	}
+	if _, quit := session.Execute("quit"); !quit {
+		t.Errorf("\nfunction Execute not ending the session for command \" quit \"")
+	}
+}
+
+func TestRun(t *testing.T) {
+	session := newTestSession(t)
+	var output strings.Builder
+	err := session.Run(strings.NewReader("step 3\n\nquit\nrooms\n"), &output)
+	correctOutput := "lem-in repl: 5 rooms, 3 ants (enter \" help \" for the list of commands)\n> " +
+		"route 1: s-a-e (2 turns), 2 ants\nroute 2: s-b-c-e (3 turns), 1 ants\nturns: 3, ant moves: 7\n" +
+		"turn 1: L1-a L2-b\nturn 2: L1-e L2-c L3-a\nturn 3: L3-e L2-e\nall ants finished after 3 turns\n> > "
+	if err != nil || output.String() != correctOutput {
+		t.Errorf("\nfunction Run not writing expected output"+
+			"\ngot: %q, %v \nexpected: %q", output.String(), err, correctOutput)
+	}
+}
@@ -0,0 +1,127 @@
+package routing
+
+import (
+	"context"
+	"io"
+	"lem-in/generate"
+	"lem-in/sys"
+	"strconv"
+	"testing"
+)
+
+/*
+benchmarkFarm generates the lines of a farm for the benchmarks, with the given number of rooms, ants
+and topology (grid farms for the exhaustive search, as their number of routes grows exponentially).
+*/
+func benchmarkFarm(b *testing.B, rooms, ants int, topology string) []string {
+	options := generate.Options{Rooms: rooms, Density: 0, Ants: ants, CoordRange: 10 * rooms,
+		Connectivity: generate.ConnectGuaranteed, Topology: topology, Seed: 1}
+	fileContents, err := generate.Generate(options)
+	if err != nil {
+		b.Fatalf("\nerror in generating farm \ngot: %v", err)
+	}
+	return fileContents
+}
+
+/*
+benchmarkSolver parses the farm and returns a Solver for it, without printing.
+*/
+func benchmarkSolver(b *testing.B, fileContents []string, algorithm string) *Solver {
+	farm, err := sys.Parse(fileContents)
+	if err != nil {
+		b.Fatalf("\nerror in parsing farm \ngot: %v", err)
+	}
+	solver := NewSolver(farm)
+	solver.Algorithm = algorithm
+	solver.EchoInput = false
+	solver.Output = io.Discard
+	return solver
+}
+
+func BenchmarkPathSearch(b *testing.B) {
+	for _, rooms := range []int{9, 12, 16, 20} {
+		fileContents := benchmarkFarm(b, rooms, 20, generate.TopologyGrid)
+		b.Run("dfs/rooms="+strconv.Itoa(rooms), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				solver := benchmarkSolver(b, fileContents, AlgorithmDFS)
+				b.StartTimer()
+				if _, err := solver.runningDFS(context.Background()); err != nil {
+					b.Fatalf("\nerror in path search \ngot: %v", err)
+				}
+			}
+		})
+	}
+	for _, rooms := range []int{100, 1000, 5000} {
+		fileContents := benchmarkFarm(b, rooms, 200, generate.TopologyRandom)
+		b.Run("flow/rooms="+strconv.Itoa(rooms), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				solver := benchmarkSolver(b, fileContents, AlgorithmFlow)
+				b.StartTimer()
+				if _, err := solver.findFlowRoutes(context.Background()); err != nil {
+					b.Fatalf("\nerror in path search \ngot: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRouteSelection(b *testing.B) {
+	for _, rooms := range []int{9, 12, 16, 20} {
+		fileContents := benchmarkFarm(b, rooms, 20, generate.TopologyGrid)
+		b.Run("rooms="+strconv.Itoa(rooms), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				solver := benchmarkSolver(b, fileContents, AlgorithmDFS)
+				allRoutes, err := solver.runningDFS(context.Background())
+				if err != nil {
+					b.Fatalf("\nerror in path search \ngot: %v", err)
+				}
+				b.StartTimer()
+				if _, err = solver.filterRoutes(context.Background(), allRoutes); err != nil {
+					b.Fatalf("\nerror in route selection \ngot: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSimulation(b *testing.B) {
+	for _, ants := range []int{10, 1000, 100000} {
+		fileContents := benchmarkFarm(b, 200, ants, generate.TopologyRandom)
+		b.Run("ants="+strconv.Itoa(ants), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				solver := benchmarkSolver(b, fileContents, AlgorithmFlow)
+				var err error
+				if solver.Routes, err = solver.filterFlowRoutes(context.Background()); err != nil {
+					b.Fatalf("\nerror in route search \ngot: %v", err)
+				}
+				if solver.AntGrouping, err = solver.calcAntGrouping(solver.Routes); err != nil {
+					b.Fatalf("\nerror in ant grouping \ngot: %v", err)
+				}
+				b.StartTimer()
+				if err = solver.executeMoves(); err != nil {
+					b.Fatalf("\nerror in simulation \ngot: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkAntGrouping(b *testing.B) {
+	routeLengths := []int{5, 6, 6, 8, 11, 12, 15, 20}
+	for _, ants := range []int{10, 1000, sys.MaxAnts} {
+		b.Run("ants="+strconv.Itoa(ants), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				DistributeAnts(routeLengths, ants)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"lem-in/sys"
+)
+
+/*
+flowEdge is a directed edge of the residual flow network used by the max-flow solver. "to" is the index
+of the node the edge points to, "rev" the index of the reverse edge within the adjacency list of "to",
+"cap" the remaining (residual) capacity, "flow" the amount of flow currently pushed through the edge and
+"cost" the number of turns taken to cross the edge (the weight of links, 0 within a room, negated for reverse edges).
+*/
+type flowEdge struct {
+	to   int
+	rev  int
+	cap  int
+	flow int
+	cost int
+}
+
+/*
+flowGraph is the residual network built from the Network of a sys.Farm. Each room is split into an
+"in" node (index 2*i) and an "out" node (index 2*i + 1), joined by an edge with the capacity of the room,
+so that every intermediate room can only be used by a single route (vertex-disjoint routes). Under
+sys.RulesTunnels, rooms are not limited and "tunnels" lists the pairs of opposite directed edges of
+each link (as [node, index within adjacency list of node]), so that each link is used by a single
+route (edge-disjoint routes), and "minCost" is set so that augmenting paths are the cheapest rather
+than the shortest (see augment), as is the case if rooms can hold several ants or tunnels are weighted.
+*/
+type flowGraph struct {
+	adj     [][]flowEdge
+	rooms   []*sys.Room
+	tunnels [][2][2]int
+	minCost bool
+}
+
+/*
+addFlowEdge adds a directed edge with the given capacity and cost from node "from" to node "to", along
+with its reverse residual edge of capacity zero and negated cost.
+*/
+func (graph *flowGraph) addFlowEdge(from, to, capacity, cost int) {
+	graph.adj[from] = append(graph.adj[from], flowEdge{to: to, rev: len(graph.adj[to]), cap: capacity, cost: cost})
+	graph.adj[to] = append(graph.adj[to], flowEdge{to: from, rev: len(graph.adj[from]) - 1, cap: 0, cost: -cost})
+}
+
+/*
+buildFlowGraph compiles the residual network from the Network of the Solver's Farm. Start and end rooms are
+given a capacity equal to the number of rooms (unlimited in practice), intermediate rooms the number of ants
+they can hold (or, under sys.RulesTunnels, the same capacity as the start and end rooms), and every link is
+written as two directed edges of capacity one (one in each direction), costing the weight of the link. If
+rooms can hold several ants under sys.RulesRooms, links other than a direct link between a start and an end
+room are instead left unlimited, so that several routes can follow the same rooms. The graph is returned along
+with the source (out node of the Start room) and sink (in node of the End room) indices. If the Farm has
+several start or end rooms (see sys.Farm.HasMultipleTerminals), the source and sink are instead a "super
+source" joined to the out node of every start room (by edges limited to the ants of the start room, if given)
+and a "super sink" joined from the in node of every end room, at no cost, and links leading into a start room
+or out of an end room are left out.
+*/
+func (solver *Solver) buildFlowGraph() (*flowGraph, int, int, error) {
+	if solver.Farm.Start == nil || solver.Farm.End == nil {
+		return nil, 0, 0, errors.New("\nERROR: internal malfunction, the function \" buildFlowGraph \" " +
+			"called while the Start and/or End rooms of the Farm are empty")
+	}
+
+	multiple := solver.Farm.HasMultipleTerminals()
+	nodes := 2 * len(solver.Farm.Network)
+	if multiple {
+		nodes += 2 // Super source and super sink
+	}
+	graph := &flowGraph{
+		adj:   make([][]flowEdge, nodes),
+		rooms: make([]*sys.Room, len(solver.Farm.Network)),
+	}
+	roomIndex := make(map[*sys.Room]int, len(solver.Farm.Network))
+	for i := range solver.Farm.Network {
+		graph.rooms[i] = &solver.Farm.Network[i]
+		roomIndex[&solver.Farm.Network[i]] = i
+	}
+
+	capacities := solver.Rules != sys.RulesTunnels && solver.Farm.HasCapacities()
+	unlimited := len(solver.Farm.Network)
+	if capacities && solver.Farm.TotalAntNbr > unlimited {
+		unlimited = solver.Farm.TotalAntNbr // More routes than rooms, if repeated
+	}
+	graph.minCost = solver.Rules == sys.RulesTunnels || capacities || solver.Farm.HasWeights()
+	source, sink := -1, -1
+	if multiple {
+		source, sink = nodes-2, nodes-1
+	}
+	for i, room := range graph.rooms {
+		capacity := room.AntCapacity()
+		if room.Class == "start" || room.Class == "end" || solver.Rules == sys.RulesTunnels {
+			capacity = unlimited
+		}
+		graph.addFlowEdge(2*i, 2*i+1, capacity, 0)
+		if multiple && room.Class == "start" && room.Ants > 0 {
+			graph.addFlowEdge(source, 2*i+1, room.Ants, 0)
+		} else if multiple && room.Class == "start" {
+			graph.addFlowEdge(source, 2*i+1, unlimited, 0)
+		} else if multiple && room.Class == "end" {
+			graph.addFlowEdge(2*i, sink, unlimited, 0)
+		} else if room == solver.Farm.Start {
+			source = 2*i + 1
+		} else if room == solver.Farm.End {
+			sink = 2 * i
+		}
+	}
+	if source < 0 || sink < 0 {
+		return nil, 0, 0, errors.New("\nERROR: internal malfunction, the function \" buildFlowGraph \" " +
+			"could not find the Start and/or End rooms in the Network of the Farm")
+	}
+
+	linkEdges := make(map[[2]int][2]int)
+	for i, room := range graph.rooms {
+		for _, link := range room.Links {
+			j, found := roomIndex[link]
+			if !found {
+				return nil, 0, 0, errors.New("\nERROR: internal malfunction, the function \" buildFlowGraph \" " +
+					"found a link to a room outside of the Network of the Farm: " + link.Name)
+			}
+			if multiple && (room.Class == "end" || link.Class == "start") {
+				continue
+			} else if capacities && !(room.Class == "start" && link.Class == "end") {
+				graph.addFlowEdge(2*i+1, 2*j, unlimited, room.Weight(link))
+			} else {
+				graph.addFlowEdge(2*i+1, 2*j, 1, room.Weight(link))
+			}
+			edge := [2]int{2*i + 1, len(graph.adj[2*i+1]) - 1}
+			if opposite, found := linkEdges[[2]int{j, i}]; found {
+				graph.tunnels = append(graph.tunnels, [2][2]int{edge, opposite})
+			} else {
+				linkEdges[[2]int{i, j}] = edge
+			}
+		}
+	}
+	return graph, source, sink, nil
+}
+
+/*
+augment performs a breadth-first search (Edmonds-Karp) on the residual network for the shortest path
+from source to sink with remaining capacity, and pushes one unit of flow along it. If minCost is set, the
+cheapest path (see cheapestPath) is used instead. It returns true if an augmenting path was found, and
+false otherwise.
+*/
+func (graph *flowGraph) augment(source, sink int) bool {
+	// parent[node] = [previous node, index of edge within adjacency list of previous node]
+	var parent [][2]int
+	if graph.minCost {
+		parent = graph.cheapestPath(source)
+	} else {
+		parent = make([][2]int, len(graph.adj))
+		for i := range parent {
+			parent[i] = [2]int{-1, -1}
+		}
+		parent[source] = [2]int{source, -1}
+
+		queue := []int{source}
+		for len(queue) > 0 && parent[sink][0] < 0 {
+			node := queue[0]
+			queue = queue[1:]
+			for i, edge := range graph.adj[node] {
+				if edge.cap > 0 && parent[edge.to][0] < 0 {
+					parent[edge.to] = [2]int{node, i}
+					queue = append(queue, edge.to)
+				}
+			}
+		}
+	}
+	if parent[sink][0] < 0 {
+		return false
+	}
+
+	// Push one unit of flow back along the path, updating the reverse edges
+	for node := sink; node != source; node = parent[node][0] {
+		prev, index := parent[node][0], parent[node][1]
+		edge := &graph.adj[prev][index]
+		edge.cap--
+		edge.flow++
+		reverse := &graph.adj[node][edge.rev]
+		reverse.cap++
+		reverse.flow--
+	}
+	return true
+}
+
+/*
+cheapestPath performs a queue-based Bellman-Ford search on the residual network for the cheapest path
+(fewest turns spent in tunnels, with tunnels taken back from earlier paths counting negatively) from the
+source to every node with remaining capacity. Augmenting along the cheapest path every time (successive
+shortest paths) keeps the total length of the routes as small as possible for each number of routes, which
+rooms shared by several routes or weighted tunnels would otherwise break. The parent of each node on its cheapest path is returned as
+[previous node, index of edge within adjacency list of previous node], with [-1, -1] for unreachable nodes.
+*/
+func (graph *flowGraph) cheapestPath(source int) [][2]int {
+	parent := make([][2]int, len(graph.adj))
+	distance := make([]int, len(graph.adj))
+	queued := make([]bool, len(graph.adj))
+	for i := range parent {
+		parent[i] = [2]int{-1, -1}
+	}
+	parent[source] = [2]int{source, -1}
+
+	queue := []int{source}
+	queued[source] = true
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		queued[node] = false
+		for i, edge := range graph.adj[node] {
+			if edge.cap > 0 && edge.to != source &&
+				(parent[edge.to][0] < 0 || distance[node]+edge.cost < distance[edge.to]) {
+				distance[edge.to] = distance[node] + edge.cost
+				parent[edge.to] = [2]int{node, i}
+				if !queued[edge.to] {
+					queued[edge.to] = true
+					queue = append(queue, edge.to)
+				}
+			}
+		}
+	}
+	return parent
+}
+
+/*
+cancelOpposingFlows removes the flow from every tunnel (see flowGraph) carrying flow in both directions,
+restoring both directed edges (and their reverse edges) to their initial capacities and removing one unit
+of flow from both rooms of the tunnel, as two routes crossing the same tunnel in opposite directions can
+always be rewired to use neither. The "in" to "out" edge of each room is the first edge of its "in" node. This keeps the flow of the
+residual network a set of edge-disjoint routes under sys.RulesTunnels.
+*/
+func (graph *flowGraph) cancelOpposingFlows() {
+	for _, tunnel := range graph.tunnels {
+		forward := &graph.adj[tunnel[0][0]][tunnel[0][1]]
+		backward := &graph.adj[tunnel[1][0]][tunnel[1][1]]
+		if forward.flow > 0 && backward.flow > 0 {
+			for _, edge := range []*flowEdge{forward, backward} {
+				edge.cap, edge.flow = 1, 0
+				reverse := &graph.adj[edge.to][edge.rev]
+				reverse.cap, reverse.flow = 0, 0
+
+				// One unit of flow less through the room the edge leads into (its "in" to "out" edge)
+				inner := &graph.adj[edge.to][0]
+				inner.cap++
+				inner.flow--
+				innerReverse := &graph.adj[inner.to][inner.rev]
+				innerReverse.cap--
+				innerReverse.flow++
+			}
+		}
+	}
+}
+
+/*
+extractRoutes decomposes the flow currently pushed through the residual network into routes, by following
+edges carrying a positive flow from the source to the sink. Each route is returned as a slice of pointers
+to rooms in the Network of the Farm, starting with a start room and ending with an end room (the super
+source and super sink, if any, are not rooms). As rooms may carry more than one unit of flow under
+sys.RulesTunnels, any loop found while following the flow is cut out of the route.
+*/
+func (graph *flowGraph) extractRoutes(source, sink int) [][]*sys.Room {
+	used := make([][]int, len(graph.adj))
+	for i := range graph.adj {
+		used[i] = make([]int, len(graph.adj[i]))
+	}
+
+	var routes [][]*sys.Room
+	for {
+		var route []*sys.Room
+		if source/2 < len(graph.rooms) {
+			route = append(route, graph.rooms[source/2])
+		}
+		node := source
+		for node != sink {
+			next := -1
+			for i, edge := range graph.adj[node] {
+				if edge.flow > used[node][i] {
+					used[node][i]++
+					next = edge.to
+					break
+				}
+			}
+			if next < 0 {
+				break
+			}
+			if next/2 < len(graph.rooms) && (next%2 == 0 || len(route) == 0) {
+				// Entering a room through its "in" node (or a start room from the super source), cutting out
+				// the loop if already on the route
+				room := graph.rooms[next/2]
+				for i := range route {
+					if route[i] == room {
+						route = route[:i]
+						break
+					}
+				}
+				route = append(route, room)
+			}
+			node = next
+		}
+		if node != sink {
+			return routes
+		}
+		routes = append(routes, route)
+	}
+}
+
+/*
+findFlowRoutes is the max-flow alternative to the exhaustive depth-first search and route combination
+tournament. It repeatedly augments the flow network with the shortest available path, decomposes the flow
+into vertex-disjoint (or, under sys.RulesTunnels, edge-disjoint) routes after each augmentation and rates the
+resulting route set with calculateRating, keeping the best rated set. The search stops once no augmenting
+path remains, or once there are as many routes as ants, or once the context is done (after at least one
+route). The best set of routes is returned in ascending order of length, along with an error value which is
+non-nil if no route could be found.
+*/
+func (solver *Solver) findFlowRoutes(ctx context.Context) ([][]*sys.Room, error) {
+	var bestRoutes [][]*sys.Room
+	var bestRating []int
+
+	graph, source, sink, err := solver.buildFlowGraph()
+	if err != nil {
+		return bestRoutes, err
+	}
+
+	for nbrRoutes := 0; nbrRoutes < solver.Farm.TotalAntNbr && (nbrRoutes == 0 || ctx.Err() == nil) &&
+		graph.augment(source, sink); nbrRoutes++ {
+		if solver.Rules == sys.RulesTunnels {
+			graph.cancelOpposingFlows()
+		}
+		routes := graph.extractRoutes(source, sink)
+		indices := routeIndices(routes)
+
+		rating, err := solver.calculateRating(routes, indices)
+		if err != nil {
+			return bestRoutes, err
+		}
+		better, err := compareRatings(rating, bestRating)
+		if err != nil {
+			return bestRoutes, err
+		}
+		if better {
+			bestRating = rating
+			bestRoutes, err = compileRoute(routes, indices)
+			if err != nil {
+				return bestRoutes, err
+			}
+		}
+	}
+
+	if len(bestRoutes) == 0 {
+		return bestRoutes, errors.New("\nERROR: invalid data format, no valid routes between " +
+			"start and end rooms could be found")
+	} else if start := solver.missingStart(bestRoutes); start != nil {
+		return bestRoutes, errors.New("\nERROR: invalid data format, no valid routes between start room \" " +
+			start.Name + " \" and end rooms could be found, which do not conflict with the routes of the other " +
+			"start rooms")
+	}
+	return bestRoutes, nil
+}
+
+/*
+filterFlowRoutes is the max-flow counterpart of filterRoutes. It writes the best set of disjoint routes found
+by findFlowRoutes to the Routes of the Solver, and checks that no room features in several of them (unless
+rooms may be shared, see checkRouteRooms). The search stops early once the context is done. A non-nil error
+is returned if any of the local function calls fail.
+*/
+func (solver *Solver) filterFlowRoutes(ctx context.Context) ([][]*sys.Room, error) {
+	var err error
+	solver.Routes, err = solver.findFlowRoutes(ctx)
+	if err != nil {
+		return solver.Routes, err
+	}
+
+	// Check that no room features in several routes
+	err = solver.checkRouteRooms()
+	if err != nil {
+		return solver.Routes, err
+	}
+	return solver.Routes, nil
+}
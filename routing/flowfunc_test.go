@@ -0,0 +1,70 @@
+package routing
+
+import (
+	"context"
+	"lem-in/sys"
+	"testing"
+)
+
+/*
+linkTestRooms writes symmetric links between the rooms of the Network of the input sys.Farm, with each
+link given as a pair of indices within the Network.
+*/
+func linkTestRooms(farm *sys.Farm, links [][2]int) {
+	for _, link := range links {
+		farm.Network[link[0]].Links = append(farm.Network[link[0]].Links, &farm.Network[link[1]])
+		farm.Network[link[1]].Links = append(farm.Network[link[1]].Links, &farm.Network[link[0]])
+	}
+}
+
+func TestFindFlowRoutes(t *testing.T) {
+	farm := &sys.Farm{}
+	solver := NewSolver(farm)
+	// Establish test variables. The shortest route (S-1-2-E) blocks both routes of the optimal
+	// disjoint pair (S-1-5-6-E and S-3-4-2-E), so the flow must be re-routed through room 1 and 2
+	farm.TotalAntNbr = 10
+	farm.Network = []sys.Room{{Name: "S", Class: "start"}, {Name: "1", Class: "intermediate"},
+		{Name: "2", Class: "intermediate"}, {Name: "3", Class: "intermediate"},
+		{Name: "4", Class: "intermediate"}, {Name: "5", Class: "intermediate"},
+		{Name: "6", Class: "intermediate"}, {Name: "E", Class: "end"}}
+	linkTestRooms(farm, [][2]int{{0, 1}, {1, 2}, {2, 7}, {0, 3}, {3, 4}, {4, 2}, {1, 5}, {5, 6}, {6, 7}})
+	farm.Start, farm.End = &farm.Network[0], &farm.Network[7]
+
+	routes, err := solver.findFlowRoutes(context.Background())
+	if err != nil {
+		t.Fatalf("\nfunction findFlowRoutes returning unexpected error for valid input"+
+			"\ngot: %v", err)
+	} else if len(routes) != 2 {
+		t.Fatalf("\nfunction findFlowRoutes not returning the expected number of routes"+
+			"\ngot: %v \nexpected: %v", len(routes), 2)
+	}
+	conflict, err := checkRouteConflict(routes[0], routes[1])
+	if conflict || err != nil {
+		t.Errorf("\nfunction findFlowRoutes returning conflicting routes"+
+			"\nroute1: %v \nroute2: %v \nerror: %v", routes[0], routes[1], err)
+	}
+	for _, route := range routes {
+		if route[0] != farm.Start || route[len(route)-1] != farm.End || len(route) != 5 {
+			t.Errorf("\nfunction findFlowRoutes returning an invalid route"+
+				"\ngot: %v", route)
+		}
+	}
+
+	// A single ant only ever needs the shortest route
+	farm.TotalAntNbr = 1
+	routes, err = solver.findFlowRoutes(context.Background())
+	if err != nil || len(routes) != 1 || len(routes[0]) != 4 {
+		t.Errorf("\nfunction findFlowRoutes not returning the shortest route for a single ant"+
+			"\ngot: %v \nerror: %v", routes, err)
+	}
+
+	// No route between start and end rooms
+	farm.Network = []sys.Room{{Name: "S", Class: "start"}, {Name: "1", Class: "intermediate"},
+		{Name: "E", Class: "end"}}
+	linkTestRooms(farm, [][2]int{{0, 1}})
+	farm.Start, farm.End = &farm.Network[0], &farm.Network[2]
+	_, err = solver.findFlowRoutes(context.Background())
+	if err == nil {
+		t.Errorf("\nfunction findFlowRoutes not returning error for disconnected start and end rooms")
+	}
+}
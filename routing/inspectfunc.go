@@ -0,0 +1,71 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"lem-in/sys"
+	"sort"
+	"strconv"
+)
+
+/*
+Paths returns the routes found by the depth-first search of the Solver's Farm (see runningDFS), in
+ascending order of length, for inspecting the steps of Run one at a time (e.g. from an interactive
+session). The search stops early once the context is done. A non-nil error is returned if no routes
+can be found.
+*/
+func (solver *Solver) Paths(ctx context.Context) ([][]*sys.Room, error) {
+	return solver.runningDFS(ctx)
+}
+
+/*
+Conflicts returns the conflict map of the input routes (see createConflictMap) under the rule set of the
+Solver: the indices of the routes conflicting with each route, by route index. A non-nil error is returned
+if the input slice of routes has a length of zero.
+*/
+func (solver *Solver) Conflicts(routes [][]*sys.Room) (map[int][]int, error) {
+	if solver.Rules == sys.RulesTunnels {
+		return createConflictMap(routes, checkTunnelConflict)
+	}
+	return createConflictMap(routes, checkRouteConflict)
+}
+
+/*
+Rate returns the rating (number of turns and number of ant moves, see calculateRating) of the combination
+of the input routes given by their indices, along with the number of ants assigned to each of those routes
+(see calcAntGrouping), in the order of the indices. Conflicts between the routes are not checked. A non-nil
+error is returned if any index is outside the range of the routes, or if the combination leaves out a start
+room with ants of its own (see missingStart).
+*/
+func (solver *Solver) Rate(routes [][]*sys.Room, routeIndices []int) ([]int, []int, error) {
+	routeCombo, err := compileRoute(routes, routeIndices)
+	if err != nil {
+		return nil, nil, err
+	}
+	if start := solver.missingStart(routeCombo); start != nil {
+		return nil, nil, errors.New("\nERROR: invalid data format, no route of the combination leaves start room \" " +
+			start.Name + " \" (with " + strconv.Itoa(start.Ants) + " ants of its own)")
+	}
+	rating, err := solver.calculateRating(routes, routeIndices)
+	if err != nil {
+		return nil, nil, err
+	}
+	sortedGrouping, err := solver.calcAntGrouping(routeCombo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Routes are sorted stably by length by compileRoute (see sortRoutes), so the same order is found here
+	order := make([]int, len(routeIndices))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return routeTurns(routes[routeIndices[order[a]]]) < routeTurns(routes[routeIndices[order[b]]])
+	})
+	antGrouping := make([]int, len(routeIndices))
+	for k, i := range order {
+		antGrouping[i] = sortedGrouping[k]
+	}
+	return rating, antGrouping, nil
+}
@@ -0,0 +1,39 @@
+package routing
+
+import (
+	"context"
+	"lem-in/sys"
+	"reflect"
+	"testing"
+)
+
+func TestRate(t *testing.T) {
+	farm, err := sys.Parse([]string{"3", "##start", "s 0 0", "a 1 0", "b 1 1", "c 2 1", "##end", "e 3 0", "s-a",
+		"a-e", "s-b", "b-c", "c-e", "a-c"})
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error"+
+			"\ngot: %v", err)
+	}
+	solver := NewSolver(farm)
+	routes, err := solver.Paths(context.Background())
+	if err != nil || len(routes) != 4 {
+		t.Fatalf("\nfunction Paths not returning expected routes"+
+			"\ngot: %v, %v", routes, err)
+	}
+
+	// The ants are listed in the order of the indices, not of the route lengths
+	rating, antGrouping, err := solver.Rate(routes, []int{2, 0})
+	if err != nil || !reflect.DeepEqual(rating, []int{3, 7}) || !reflect.DeepEqual(antGrouping, []int{1, 2}) {
+		t.Errorf("\nfunction Rate not returning expected rating and ants"+
+			"\ngot: %v, %v, %v", rating, antGrouping, err)
+	}
+	if _, _, err = solver.Rate(routes, []int{0, 4}); err == nil {
+		t.Errorf("\nfunction Rate not returning error for index out of range")
+	}
+
+	conflictMap, err := solver.Conflicts(routes)
+	if err != nil || !reflect.DeepEqual(conflictMap[0], []int{1, 3}) || len(conflictMap[2]) != 2 {
+		t.Errorf("\nfunction Conflicts not returning expected conflicts"+
+			"\ngot: %v, %v", conflictMap, err)
+	}
+}
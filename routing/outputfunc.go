@@ -0,0 +1,126 @@
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"lem-in/sys"
+)
+
+/*
+jsonRoom, jsonRating and jsonSolution describe the structure of the machine-readable (JSON) output
+written by WriteJSON.
+*/
+type jsonRoom struct {
+	Name     string `json:"name"`
+	Class    string `json:"class"`
+	Coords   []int  `json:"coords"`
+	Capacity int    `json:"capacity,omitempty"`
+	Ants     int    `json:"ants,omitempty"`
+}
+
+type jsonRating struct {
+	Turns    int `json:"turns"`
+	AntMoves int `json:"antMoves"`
+}
+
+type jsonSolution struct {
+	Ants        int         `json:"ants"`
+	Rooms       []jsonRoom  `json:"rooms"`
+	Links       [][2]string `json:"links"`
+	Weights     []int       `json:"weights,omitempty"`
+	Routes      [][]string  `json:"routes"`
+	AntGrouping []int       `json:"antGrouping"`
+	Rating      jsonRating  `json:"rating"`
+	Interrupted bool        `json:"interrupted"`
+	Turns       [][]Move    `json:"turns"`
+}
+
+/*
+farmLinks takes an input sys.Farm and returns each of its links once, as a pair of room names, in the
+order in which the rooms appear in the Network of the Farm.
+*/
+func farmLinks(farm *sys.Farm) [][2]string {
+	links := [][2]string{}
+	roomIndex := make(map[string]int, len(farm.Network))
+	for i, room := range farm.Network {
+		roomIndex[room.Name] = i
+	}
+	for i, room := range farm.Network {
+		for _, link := range room.Links {
+			if roomIndex[link.Name] > i {
+				links = append(links, [2]string{room.Name, link.Name})
+			}
+		}
+	}
+	return links
+}
+
+/*
+farmWeights takes an input sys.Farm and returns the weight of each of its links (see sys.Room.Weight), in
+the order of farmLinks, or nil if no tunnel of the Farm is weighted.
+*/
+func farmWeights(farm *sys.Farm) []int {
+	if !farm.HasWeights() {
+		return nil
+	}
+	weights := []int{}
+	roomIndex := make(map[string]int, len(farm.Network))
+	for i, room := range farm.Network {
+		roomIndex[room.Name] = i
+	}
+	for i, room := range farm.Network {
+		for _, link := range room.Links {
+			if roomIndex[link.Name] > i {
+				weights = append(weights, room.Weight(link))
+			}
+		}
+	}
+	return weights
+}
+
+/*
+WriteJSON writes the solution found by Run to the io.Writer as a single JSON document: the farm (ants, rooms
+with their coordinates and any capacity or number of ants of a start room, and links with their weights if
+any tunnel is weighted), the chosen Routes (as room names), the number of ants assigned to each route, the
+rating of the routes (number of turns and total number of ant moves), whether the route search was
+interrupted (possibly sub-optimal routes), and each turn as an array of {ant, from, to} moves. Run must have
+been called with RecordTurns set to true. A non-nil error is returned if the Solver has not been run, or if
+writing fails.
+*/
+func (solver *Solver) WriteJSON(writer io.Writer) error {
+	if len(solver.Routes) == 0 || len(solver.Rating) != 2 {
+		return errors.New("\nERROR: internal malfunction, the function \" WriteJSON \" called " +
+			"before a solution was found by Run")
+	}
+
+	solution := jsonSolution{
+		Ants:        solver.Farm.TotalAntNbr,
+		Rooms:       make([]jsonRoom, 0, len(solver.Farm.Network)),
+		Links:       farmLinks(solver.Farm),
+		Weights:     farmWeights(solver.Farm),
+		Routes:      make([][]string, 0, len(solver.Routes)),
+		AntGrouping: solver.InitialGrouping,
+		Rating:      jsonRating{Turns: solver.Rating[0], AntMoves: solver.Rating[1]},
+		Interrupted: solver.Interrupted,
+		Turns:       solver.Turns,
+	}
+	for _, room := range solver.Farm.Network {
+		solution.Rooms = append(solution.Rooms, jsonRoom{Name: room.Name, Class: room.Class, Coords: room.Coords,
+			Capacity: room.Capacity, Ants: room.Ants})
+	}
+	for _, route := range solver.Routes {
+		names := make([]string, 0, len(route))
+		for _, room := range route {
+			names = append(names, room.Name)
+		}
+		solution.Routes = append(solution.Routes, names)
+	}
+	if solution.Turns == nil {
+		solution.Turns = [][]Move{}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(solution)
+}
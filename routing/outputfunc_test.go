@@ -0,0 +1,64 @@
+package routing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"lem-in/sys"
+	"reflect"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	// Establish test variables
+	farm, err := sys.Parse([]string{"4", "##start", "0 0 3", "2 2 5", "3 4 0", "##end", "1 8 3",
+		"0-2", "2-3", "3-1"})
+	if err != nil {
+		t.Fatalf("\nerror in parsing test farm \ngot: %v", err)
+	}
+	solver := NewSolver(farm)
+	solver.Output = io.Discard
+	solver.RecordTurns = true
+
+	// Writing before the Solver has been run
+	var output bytes.Buffer
+	if err = solver.WriteJSON(&output); err == nil {
+		t.Errorf("\nfunction WriteJSON not returning error when called before Run")
+	}
+
+	if err = solver.Run(context.Background()); err != nil {
+		t.Fatalf("\nSolver returning unexpected error for test farm \ngot: %v", err)
+	}
+	output.Reset()
+	if err = solver.WriteJSON(&output); err != nil {
+		t.Fatalf("\nfunction WriteJSON returning unexpected error \ngot: %v", err)
+	}
+	var solution jsonSolution
+	if err = json.Unmarshal(output.Bytes(), &solution); err != nil {
+		t.Fatalf("\nfunction WriteJSON not writing valid JSON \ngot: %v \nerror: %v", output.String(), err)
+	}
+
+	if solution.Ants != 4 || len(solution.Rooms) != 4 ||
+		!reflect.DeepEqual(solution.Rooms[1], jsonRoom{Name: "2", Class: "intermediate", Coords: []int{2, 5}}) {
+		t.Errorf("\nfunction WriteJSON not writing the expected farm"+
+			"\ngot: %v ants, rooms %v", solution.Ants, solution.Rooms)
+	}
+	if !reflect.DeepEqual(solution.Links, [][2]string{{"0", "2"}, {"2", "3"}, {"3", "1"}}) {
+		t.Errorf("\nfunction WriteJSON not writing the expected links"+
+			"\ngot: %v", solution.Links)
+	}
+	if !reflect.DeepEqual(solution.Routes, [][]string{{"0", "2", "3", "1"}}) ||
+		!reflect.DeepEqual(solution.AntGrouping, []int{4}) {
+		t.Errorf("\nfunction WriteJSON not writing the expected routes and ant grouping"+
+			"\ngot: %v %v", solution.Routes, solution.AntGrouping)
+	}
+	if solution.Rating != (jsonRating{Turns: 6, AntMoves: 12}) || len(solution.Turns) != 6 {
+		t.Errorf("\nfunction WriteJSON not writing the expected rating and number of turns"+
+			"\ngot: %v %v", solution.Rating, len(solution.Turns))
+	}
+	if !reflect.DeepEqual(solution.Turns[0], []Move{{Ant: 1, From: "0", To: "2"}}) {
+		t.Errorf("\nfunction WriteJSON not writing the expected first turn"+
+			"\ngot: %v", solution.Turns[0])
+	}
+}
@@ -1,19 +1,80 @@
 package routing
 
 import (
+	"bufio"
+	"context"
 	"errors"
-	"fmt"
+	"io"
 	"lem-in/sys"
+	"math"
+	"os"
+	"sort"
 	"strconv"
-	"strings"
 )
 
-var (
-	CurrentTurnStr    string // For moving ants
-	AntID             = 1    // For moving ants
-	TotalAntsFinished = 0    // For moving ants
+/*
+Solver holds the routing state for solving a single sys.Farm: the chosen Routes and AntGrouping, the
+ants on each route and the counters used while moving ants, and the options used by Run. The Farm itself is
+only read, so several farms (or the same Farm, by several Solvers) can be solved concurrently, without
+interference.
+*/
+type Solver struct {
+	Farm              *sys.Farm
+	Algorithm         string             // Route-finding algorithm used by Run
+	Rules             string             // Rule set for moving ants: sys.RulesRooms or sys.RulesTunnels
+	EchoInput         bool               // Print the input file contents before the moves
+	Output            io.Writer          // Destination of the printed input file contents and moves
+	RecordTurns       bool               // Keep the moves of every turn in Turns
+	SummaryOnly       bool               // Only print the number of turns, instead of the moves of every turn
+	OnStage           func(stage string) // Called by Run at the start of each stage (e.g. for timing), if non-nil
+	MaxPaths          int                // Maximum number of paths found by the depth-first search (0 = no limit)
+	Workers           int                // Number of goroutines rating route combinations in parallel (at least 1)
+	Interrupted       bool               // Route search stopped early (see Run): Routes may be sub-optimal
 	Routes            [][]*sys.Room
-	AntGrouping       []int
+	AntGrouping       []int  // Ants still to be sent down each route (counted down while moving ants)
+	InitialGrouping   []int  // Ants assigned to each route
+	Rating            []int  // [number of turns, number of ant moves] of the Routes
+	CurrentTurn       []Move // For moving ants
+	Turns             [][]Move
+	TurnCount         int                   // Number of turns taken to move all ants
+	AntID             int                   // For moving ants
+	TotalAntsFinished int                   // For moving ants
+	routeAnts         [][]int               // Ant at each position of each route (0 = none), see trackRouteAnts
+	routeSlots        [][]int               // Room at each position of each route, by index (-1 = in transit)
+	roomAnts          map[*sys.Room]int     // Ants in each room, for rooms holding several ants (see countMove)
+	usedTunnels       map[[2]*sys.Room]bool // Tunnels used in the current turn, under sys.RulesTunnels
+	startAntIDs       map[*sys.Room]int     // Next ant to leave each start room, if given its own ants
+}
+
+/*
+Move is a single ant movement within a turn, from one room to the next (by room name).
+*/
+type Move struct {
+	Ant  int    `json:"ant"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+const (
+	AlgorithmDFS  = "dfs"  // Depth-first search (see DefaultMaxPaths) and route combination tournament
+	AlgorithmFlow = "flow" // Max-flow (vertex-disjoint augmenting paths)
+)
+
+/*
+DefaultMaxPaths is the MaxPaths of a new Solver: the number of paths found by the depth-first search is
+capped, as every path found adds to the combinations rated when selecting routes. Interrupted is set if
+the cap is reached, as the routes selected may then be sub-optimal.
+*/
+const DefaultMaxPaths = 1000
+
+/*
+Stages of Run, as passed to the OnStage function of the Solver. The max-flow algorithm finds and
+selects its routes in one go, within StageSearch.
+*/
+const (
+	StageSearch   = "search"   // Path search (depth-first search, or max-flow)
+	StageSelect   = "select"   // Route selection (combination tournament), ant grouping and rating
+	StageSimulate = "simulate" // Moving (and printing) the ants
 )
 
 // PRINTING FUNCTIONS FOR DE-BUGGING
@@ -58,24 +119,35 @@ func maxInt(int1, int2 int) (int, error) {
 }
 
 /*
-findByName takes an input room name in the form of a string, and searches the global sys.Network
-variable for the room with a matching name. It then returns a pointer to this room within the global
-Network struct.
+findByName takes an input room name in the form of a string, and searches the Network of the Farm
+for the room with a matching name. It then returns a pointer to this room within the Network.
 */
-func findByName(name string) *sys.Room {
-	for _, room := range sys.Network {
-		if room.Name == name {
-			return &room
+func (solver *Solver) findByName(name string) *sys.Room {
+	for i := range solver.Farm.Network {
+		if solver.Farm.Network[i].Name == name {
+			return &solver.Farm.Network[i]
 		}
-
 	}
 	return nil
 }
 
+/*
+routeTurns returns the number of turns taken by an ant to follow the input route from its first room to
+its last room: the sum of the weights of its tunnels (see sys.Room.Weight), which is one less than the
+number of rooms of the route if no tunnel is weighted.
+*/
+func routeTurns(route []*sys.Room) int {
+	turns := 0
+	for i := 1; i < len(route); i++ {
+		turns += route[i-1].Weight(route[i])
+	}
+	return turns
+}
+
 /*
 sortRoutes takes an input slice of routes ([][]*sys.Room) and applies a BUBBLE SORT algorithm to sort them
-in ascending order of route length. The sorted slice is then returned, along with a non-nil error if the input
-slice of routes has a length of zero.
+in ascending order of route length (in turns, see routeTurns). The sorted slice is then returned, along with
+a non-nil error if the input slice of routes has a length of zero.
 */
 func sortRoutes(setRoutes [][]*sys.Room) ([][]*sys.Room, error) {
 	if len(setRoutes) == 0 {
@@ -86,7 +158,7 @@ func sortRoutes(setRoutes [][]*sys.Room) ([][]*sys.Room, error) {
 	for changeCounter > 0 {
 		changeCounter = 0
 		for i := 1; i < len(setRoutes); i++ {
-			if len(setRoutes[i]) < len(setRoutes[i-1]) {
+			if routeTurns(setRoutes[i]) < routeTurns(setRoutes[i-1]) {
 				setRoutes[i], setRoutes[i-1] = setRoutes[i-1], setRoutes[i]
 				changeCounter++
 			}
@@ -96,96 +168,259 @@ func sortRoutes(setRoutes [][]*sys.Room) ([][]*sys.Room, error) {
 }
 
 /*
-convertStringToSlice takes an input string of paths, with individual routes assumed to be separated
-by a ",", and individual rooms within the paths separated by a " ". The function parses this input
-string to produce a slice of strings, with each string representing an individual route. It then
-further separates these strings according to room names, calling the local findByName function to
-write to the eventual output variable, a slice of routes, with each route itself a slice of pointers
-to rooms in the global Network variable (*sys.Room).
+searchGraph holds the Network of a sys.Farm by room index for the depth-first search: "rooms" are
+pointers to the rooms of the Network, "links" the indices of the linked rooms of each room (ordered
+closest to an end room first), "weights" the number of turns taken to reach each of those linked rooms
+and "distance" the number of turns from each room to the closest end room (-1 if no end room can be
+reached). "starts" are the indices of the start rooms, and "isEnd" marks the end rooms.
+*/
+type searchGraph struct {
+	rooms    []*sys.Room
+	links    [][]int
+	weights  [][]int
+	distance []int
+	starts   []int
+	isEnd    []bool
+}
+
+/*
+buildSearchGraph compiles the searchGraph of the Network of the Solver's Farm, calculating the distance
+of every room to the closest end room with a breadth-first search from all end rooms (repeated for rooms
+reached sooner through slower tunnels, if tunnels are weighted). A non-nil error is returned if no start
+and / or end room of the Farm can be found in its Network.
+*/
+func (solver *Solver) buildSearchGraph() (*searchGraph, error) {
+	graph := &searchGraph{
+		rooms:    make([]*sys.Room, len(solver.Farm.Network)),
+		links:    make([][]int, len(solver.Farm.Network)),
+		weights:  make([][]int, len(solver.Farm.Network)),
+		distance: make([]int, len(solver.Farm.Network)),
+		isEnd:    make([]bool, len(solver.Farm.Network)),
+	}
+	roomIndex := make(map[*sys.Room]int, len(solver.Farm.Network))
+	var queue []int
+	for i := range solver.Farm.Network {
+		graph.rooms[i] = &solver.Farm.Network[i]
+		graph.distance[i] = -1
+		roomIndex[graph.rooms[i]] = i
+		if graph.rooms[i].Class == "start" {
+			graph.starts = append(graph.starts, i)
+		} else if graph.rooms[i].Class == "end" {
+			graph.isEnd[i] = true
+			graph.distance[i] = 0
+			queue = append(queue, i)
+		}
+	}
+	if len(graph.starts) == 0 || len(queue) == 0 {
+		return nil, errors.New("\nERROR: internal malfunction, the function \" buildSearchGraph \" " +
+			"could not find the Start and/or End rooms in the Network of the Farm")
+	}
+	for i, room := range graph.rooms {
+		for _, link := range room.Links {
+			if j, found := roomIndex[link]; found {
+				graph.links[i] = append(graph.links[i], j)
+				graph.weights[i] = append(graph.weights[i], room.Weight(link))
+			}
+		}
+	}
+
+	// Breadth-first search from the end rooms (links are two-way, with the same weight both ways)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for k, next := range graph.links[current] {
+			if distance := graph.distance[current] + graph.weights[current][k]; graph.distance[next] < 0 ||
+				distance < graph.distance[next] {
+				graph.distance[next] = distance
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	for i := range graph.links {
+		sort.Stable(linkOrder{graph: graph, room: i})
+	}
+	return graph, nil
+}
+
+/*
+linkOrder sorts the links (and their weights) of a room of a searchGraph by the number of turns from the
+room to the closest end room through each link, closest first.
+*/
+type linkOrder struct {
+	graph *searchGraph
+	room  int
+}
+
+func (order linkOrder) Len() int {
+	return len(order.graph.links[order.room])
+}
+
+func (order linkOrder) Less(a, b int) bool {
+	links, weights := order.graph.links[order.room], order.graph.weights[order.room]
+	return weights[a]+order.graph.distance[links[a]] < weights[b]+order.graph.distance[links[b]]
+}
+
+func (order linkOrder) Swap(a, b int) {
+	links, weights := order.graph.links[order.room], order.graph.weights[order.room]
+	links[a], links[b] = links[b], links[a]
+	weights[a], weights[b] = weights[b], weights[a]
+}
+
+/*
+searchFrame is an entry of the explicit stack of findPaths: the index of a room on the current path,
+the position within its links of the next room to be tried, and the number of turns taken to reach it.
+*/
+type searchFrame struct {
+	room  int
+	next  int
+	turns int
+}
+
+/*
+findPaths performs a depth-first search from each start room to any end room of the searchGraph (see
+findPathsFrom), and returns every path found as a route of pointers to the rooms of the Network, the paths
+of each start room in turn, along with true if the search of any start room was cut short by maxPaths.
+Paths never pass through another start room, nor carry on past an end room.
 */
-func convertStringToSlice(strAllPaths string) ([][]*sys.Room, error) {
-	strArrAllPath := strings.Split(strAllPaths, ",")
-	arrAllPaths := make([][]*sys.Room, len(strArrAllPath))
-	for j, path := range strArrAllPath {
-		arrPath := strings.Split(path, " ")
-		for _, name := range arrPath {
-			arrAllPaths[j] = append(arrAllPaths[j], findByName(name))
+func (graph *searchGraph) findPaths(ctx context.Context, maxTurns, maxPaths int) ([][]*sys.Room, bool) {
+	var allRoutes [][]*sys.Room
+	capped := false
+	visited := make([]bool, len(graph.rooms))
+	for _, start := range graph.starts {
+		visited[start] = true
+	}
+	for _, start := range graph.starts {
+		if graph.distance[start] >= 0 {
+			routes, cappedFrom := graph.findPathsFrom(ctx, start, visited, maxTurns, maxPaths)
+			allRoutes, capped = append(allRoutes, routes...), capped || cappedFrom
+			visited[start] = true
 		}
 	}
-	return arrAllPaths, nil
+	return allRoutes, capped
 }
 
 /*
-dfsString is a function that performs a depth-first search on a given room in a system of interconnected rooms.
-It returns a string with all paths from the starting room to the end room, separated by commas.
-The function takes a pointer to the current room being searched (currentRoom *sys.Room), a string with the
-current path taken (strPath string), and a string with all paths found so far (strAllPaths string).
-If the current room has already been visited, the function returns strAllPaths. Otherwise, it marks the
-current room as visited and adds the room's name to the current path. If the current room is the end room,
-the function adds the current path to strAllPaths and returns the resulting string, with all paths separated
-by commas. If the current room is not the end room, the function iterates over all linked rooms and calls itself
-recursively on each one. Finally, the function marks the current room as unvisited and returns a string with
-all the compiled paths.
+countOpenLinks returns the number of links of the room of the searchFrame, from its next link onwards,
+which can still be followed: to a room not yet visited, from which an end room can be reached within
+maxTurns turns.
 */
-func dfsString(currentRoom *sys.Room, strPath, strAllPaths string) string {
-	if currentRoom.Visited {
-		return strAllPaths
+func (graph *searchGraph) countOpenLinks(frame searchFrame, visited []bool, maxTurns int) int {
+	count := 0
+	for i := frame.next; i < len(graph.links[frame.room]); i++ {
+		next := graph.links[frame.room][i]
+		if !visited[next] && graph.distance[next] >= 0 &&
+			frame.turns+graph.weights[frame.room][i]+graph.distance[next] <= maxTurns {
+			count++
+		}
 	}
-	currentRoom.Visited = true
-	strPath += " " + currentRoom.Name
-	if currentRoom.Name == sys.End.Name {
-		currentRoom.Visited = false
-		strAllPaths += "," + strPath[1:]
-		return strAllPaths
-	} else {
-		for _, next := range currentRoom.Links {
-			strAllPaths = dfsString(next, strPath, strAllPaths)
+	return count
+}
+
+/*
+findPathsFrom performs a depth-first search from the given start room to any end room of the
+searchGraph, with an explicit stack rather than recursion, and returns every path found (with no room
+visited twice) as a route of pointers to the rooms of the Network. Partial paths are pruned as soon as
+they can no longer reach an end room within maxTurns turns (the number of moves, if no tunnel is
+weighted). As the links of each room are tried closest to an end room first, the shortest paths tend to
+be found first (and the first path is found without backtracking). The search stops once the context is
+done (cancelled or expired) and at least one path has been found.
+
+If maxPaths > 0, at most maxPaths paths are found, shared evenly between the open links of the start
+room (see countOpenLinks), any share left unused by a link being passed on to the next ones, so that the
+paths kept do not all share the first branch searched. True is returned along with the paths if the
+search was cut short by the cap.
+*/
+func (graph *searchGraph) findPathsFrom(ctx context.Context, start int, visited []bool, maxTurns,
+	maxPaths int) ([][]*sys.Room, bool) {
+	var allRoutes [][]*sys.Room
+	capped := false
+	branchQuota, branchPaths := 0, 0
+	visited[start] = true
+	stack := []searchFrame{{room: start}}
+
+	for step := 0; len(stack) > 0; step++ {
+		// The context is only checked every so often, as the steps themselves are cheap
+		if step%1024 == 0 && len(allRoutes) > 0 && ctx.Err() != nil {
+			break
 		}
+		top := &stack[len(stack)-1]
+		if graph.isEnd[top.room] || top.next >= len(graph.links[top.room]) {
+			if graph.isEnd[top.room] {
+				route := make([]*sys.Room, len(stack))
+				for i, frame := range stack {
+					route[i] = graph.rooms[frame.room]
+				}
+				allRoutes = append(allRoutes, route)
+				branchPaths++
+			}
+			visited[top.room] = false
+			stack = stack[:len(stack)-1]
+
+			// Once the share of the current first link is used up, the rest of its branch is left
+			if branchQuota > 0 && branchPaths >= branchQuota && len(stack) > 1 {
+				for _, frame := range stack[1:] {
+					capped = capped || graph.countOpenLinks(frame, visited, maxTurns) > 0
+				}
+				for _, frame := range stack[1:] {
+					visited[frame.room] = false
+				}
+				stack = stack[:1]
+			}
+			continue
+		}
+
+		next, turns := graph.links[top.room][top.next], top.turns+graph.weights[top.room][top.next]
+		if visited[next] || graph.distance[next] < 0 || turns+graph.distance[next] > maxTurns {
+			top.next++
+			continue
+		}
+		if len(stack) == 1 && maxPaths > 0 {
+			// The paths left are split between this link and the open links after it
+			links, left := graph.countOpenLinks(*top, visited, maxTurns), maxPaths-len(allRoutes)
+			if left <= 0 {
+				capped = true
+				break
+			}
+			branchQuota, branchPaths = (left+links-1)/links, 0
+		}
+		top.next++
+		visited[next] = true
+		stack = append(stack, searchFrame{room: next, turns: turns})
+	}
+	// Rooms left on the stack (search stopped early) are no longer visited
+	for _, frame := range stack {
+		visited[frame.room] = false
 	}
-	currentRoom.Visited = false
-	return strAllPaths
+	return allRoutes, capped
 }
 
 /*
 runningDFS is a function that calls a depth-first search on a system of interconnected rooms to find all
-routes from the start room to the end room. It returns a slice of slices of pointers to Room objects,
-representing the routes ordered in terms of ascending length, and an error value. If an error is returned
-at any point, or if no valid routes are found, the function returns an empty slice of slices and the non-nil
-error value.
-*/
-func runningDFS() ([][]*sys.Room, error) {
-	// Initialise working variables
-	var strAllPaths string
-	var err error
+routes from the start room(s) to the end room(s). It returns a slice of slices of pointers to Room objects,
+representing the routes ordered in terms of ascending length, and an error value. Routes taking more
+turns than the number of turns needed to send all ants down the shortest route (see turnLimit) can never
+be used, and are not searched. At most MaxPaths routes are returned from each start room (if MaxPaths >
+0), in which case Interrupted is set to true if the search was cut short. Once the context is done, the
+routes found so far are returned. If an error is returned at any point, or if no valid routes are found,
+the function returns an empty slice of slices and the non-nil error value.
+*/
+func (solver *Solver) runningDFS(ctx context.Context) ([][]*sys.Room, error) {
 	var allRoutes [][]*sys.Room
 
-	if sys.Start == nil || sys.End == nil {
+	if solver.Farm.Start == nil || solver.Farm.End == nil {
 		return allRoutes, errors.New("\nERROR: internal malfunction, the function \" runningDFS \" " +
-			"called while the sys.Start and/or sys.End rooms are empty")
+			"called while the solver.Farm.Start and/or solver.Farm.End rooms are empty")
 	}
-
-	// Perform call depth-first-search algorithms, and convert to relevant outputs.
-	strAllPaths = dfsString(sys.Start, "", strAllPaths)
+	graph, err := solver.buildSearchGraph()
 	if err != nil {
 		return allRoutes, err
 	}
-
-	// Return error if no valid routes found
-	if len(strAllPaths) == 0 {
-		return allRoutes, errors.New("\nERROR: invalid data format, no valid routes between " +
-			"start and end rooms could be found")
-	}
-
-	allRoutes, err = convertStringToSlice(strAllPaths[1:])
+	maxTurns, err := solver.turnLimit(graph)
 	if err != nil {
 		return allRoutes, err
 	}
-
-	// Return error if no valid routes found
-	if len(allRoutes) == 0 {
-		return allRoutes, errors.New("\nERROR: invalid data format, no valid routes between " +
-			"start and end rooms could be found")
-	}
+	allRoutes, solver.Interrupted = graph.findPaths(ctx, maxTurns, solver.MaxPaths)
 
 	// Sort routes in ascending order of length before returning
 	allRoutes, err = sortRoutes(allRoutes)
@@ -196,11 +431,50 @@ func runningDFS() ([][]*sys.Room, error) {
 	return allRoutes, nil
 }
 
+/*
+turnLimit returns the best number of turns known before searching the searchGraph for routes: all ants
+sent down the shortest route (from the closest start room), or, if the number of ants of each start room
+is given (see sys.Farm.HasStartAnts), the ants of every start room sent down its own shortest route. A
+non-nil error is returned if no end room can be reached (from a start room with ants of its own).
+*/
+func (solver *Solver) turnLimit(graph *searchGraph) (int, error) {
+	limit := -1
+	startAnts := solver.Farm.HasStartAnts()
+	for _, start := range graph.starts {
+		distance := graph.distance[start]
+		if distance < 0 && startAnts {
+			return 0, errors.New("\nERROR: invalid data format, no valid routes between start room \" " +
+				graph.rooms[start].Name + " \" and end rooms could be found")
+		} else if distance < 0 {
+			continue
+		}
+		ants := solver.Farm.TotalAntNbr
+		if startAnts {
+			ants = graph.rooms[start].Ants
+		}
+		ants, err := maxInt(ants, 1)
+		if err != nil {
+			return 0, err
+		}
+		if turns := distance + ants - 1; limit < 0 || (startAnts && turns > limit) || (!startAnts && turns < limit) {
+			limit = turns
+		}
+	}
+
+	// Return error if no valid routes found
+	if limit < 0 {
+		return 0, errors.New("\nERROR: invalid data format, no valid routes between " +
+			"start and end rooms could be found")
+	}
+	return limit, nil
+}
+
 /*
 checkRouteConflict takes two input routes ([]*Room) and scans their respective nodes for conflicts.
-If any of the intermediate nodes are found in both routes, a "true" boolean is returned. If no
-duplicate intermediate nodes are found, a "false" boolean is returned. An error value is also returned
-in all cases, and is non-nil if input errors are detected.
+If any of the intermediate nodes holding a single ant (see sys.Room.AntCapacity) are found in both routes,
+a "true" boolean is returned. If no such duplicate intermediate nodes are found, a "false" boolean is
+returned (rooms holding several ants are checked for the whole route combination by capacityCheck). An
+error value is also returned in all cases, and is non-nil if input errors are detected.
 */
 func checkRouteConflict(route1, route2 []*sys.Room) (bool, error) {
 	if len(route1) == 0 || len(route2) == 0 {
@@ -215,7 +489,29 @@ func checkRouteConflict(route1, route2 []*sys.Room) (bool, error) {
 			if j == 0 || j == len(route2)-1 {
 				continue // Skip start / end room comparisons
 			}
-			if room1.Name == room2.Name {
+			if room1.Name == room2.Name && room1.AntCapacity() == 1 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+/*
+checkTunnelConflict is the counterpart of checkRouteConflict for sys.RulesTunnels, where routes may share
+rooms: a "true" boolean is returned if any tunnel (pair of consecutive rooms, in either direction, including
+the tunnels from the start room and to the end room) is found in both input routes, and "false" otherwise.
+The error value is non-nil if input errors are detected.
+*/
+func checkTunnelConflict(route1, route2 []*sys.Room) (bool, error) {
+	if len(route1) == 0 || len(route2) == 0 {
+		return false, errors.New("\nERROR: internal malfunction, route of zero length used as " +
+			"input to \" checkTunnelConflict \" function")
+	}
+	for i := 1; i < len(route1); i++ {
+		for j := 1; j < len(route2); j++ {
+			if (route1[i-1] == route2[j-1] && route1[i] == route2[j]) ||
+				(route1[i-1] == route2[j] && route1[i] == route2[j-1]) {
 				return true, nil
 			}
 		}
@@ -226,11 +522,13 @@ func checkRouteConflict(route1, route2 []*sys.Room) (bool, error) {
 /*
 createConflictMap takes an input slice of routes, and parses each one to construct a map where each key
 is the integer index of a particular route within the input slice of routes, and the corresponding values are
-the integer indices of all routes within the input slice that conflict with the route specified in the key.
-The map is then returned, along with an error value, which is non-nil in the event that any local function
-calls return an error, or if the input slice of routes has a length of zero.
+the integer indices of all routes within the input slice that conflict with the route specified in the key,
+according to the checkConflict function (checkRouteConflict or checkTunnelConflict). The map is then returned,
+along with an error value, which is non-nil in the event that any local function calls return an error, or if
+the input slice of routes has a length of zero.
 */
-func createConflictMap(allRoutes [][]*sys.Room) (map[int][]int, error) {
+func createConflictMap(allRoutes [][]*sys.Room,
+	checkConflict func(route1, route2 []*sys.Room) (bool, error)) (map[int][]int, error) {
 	// Initialize output variables
 	conflictMap := make(map[int][]int, len(allRoutes))
 	var hasConflict bool
@@ -254,7 +552,7 @@ func createConflictMap(allRoutes [][]*sys.Room) (map[int][]int, error) {
 			}
 
 			// Check if the current route conflicts with the route being checked
-			hasConflict, err = checkRouteConflict(route1, route2)
+			hasConflict, err = checkConflict(route1, route2)
 			if err != nil {
 				return conflictMap, err
 			} else if hasConflict {
@@ -270,46 +568,105 @@ func createConflictMap(allRoutes [][]*sys.Room) (map[int][]int, error) {
 }
 
 /*
-findBestPath is a function that takes a slice of slices of pointers to Room objects representing routes, and
-a slice of integers representing the number of ants assigned to each route. For each route, it calculates the
-total length by adding the number of ants already assigned to it to the actual length of the route. If this
-total length is less than the current minimum length, the function updates the minimum length and index
-variables. It returns the index of the route with the minimum length, taking into account the number of ants
-already assigned to it.
+DistributeAnts shares a number of ants between routes of the given lengths (e.g. number of rooms), so that
+all ants reach the end room in the least number of turns. It is the closed form ("water-filling") of sending
+each ant in turn down the route where it would finish first, i.e. the route with the least ants already
+assigned plus route length (the first such route in case of a tie): with every route filled up to the same
+level T (ants + length), T is the lowest level which fits all ants, and the ants left over at level T go to
+the first routes which reach it. The number of ants per route is returned, in the order of the input lengths,
+in O(routes log routes) time whatever the number of ants.
 */
-func findBestPath(routeCombo [][]*sys.Room, antGrouping []int) int {
-	minLength := antGrouping[0] + len(routeCombo[0])
-	minIndex := 0
-	for i, route := range routeCombo {
-		if (antGrouping[i] + len(route)) < minLength {
-			minLength = antGrouping[i] + len(route)
-			minIndex = i
+func DistributeAnts(routeLengths []int, ants int) []int {
+	grouping := make([]int, len(routeLengths))
+	if len(routeLengths) == 0 || ants <= 0 {
+		return grouping
+	}
+	sorted := append([]int{}, routeLengths...)
+	sort.Ints(sorted)
+
+	// Find the level T with k routes in use: the lowest T for which the slots (level values) of the
+	// k shortest routes up to T, k * (T + 1) - sum of lengths, fit all ants
+	level, lengthSum := 0, 0
+	for k := 1; k <= len(sorted); k++ {
+		lengthSum += sorted[k-1]
+		level = (ants+lengthSum+k-1)/k - 1
+		if k == len(sorted) || level < sorted[k] {
+			break
 		}
 	}
-	return minIndex
+
+	// Fill every route up to level T - 1, then hand out the remaining ants at level T in route order
+	remaining := ants
+	for i, length := range routeLengths {
+		if length < level {
+			grouping[i] = level - length
+			remaining -= grouping[i]
+		}
+	}
+	for i, length := range routeLengths {
+		if remaining > 0 && length <= level {
+			grouping[i]++
+			remaining--
+		}
+	}
+	return grouping
 }
 
 /*
 calcAntGrouping is a function that takes a slice of slices of pointers to Room objects representing routes,
-and iterates over the total number of ants (referenced to by the global sys.TotalAntNbr variable) and assigns
-each one to the best route using the findBestPath function. Finally, it returns the ant grouping slice and an
-error value, which is non-nil if the input slice has a length of zero.
+and shares the total number of ants (referenced to by the TotalAntNbr of the Farm) between them with the
+DistributeAnts function, using the number of turns taken by each route (see routeTurns) as its length. If the
+number of ants of each start room is given (see sys.Farm.HasStartAnts), the ants of each start room are
+shared between the routes leaving it instead. Finally, it returns the ant
+grouping slice and an error value, which is non-nil if the input slice has a length of zero.
 */
-func calcAntGrouping(routeCombo [][]*sys.Room) ([]int, error) {
+func (solver *Solver) calcAntGrouping(routeCombo [][]*sys.Room) ([]int, error) {
 	if len(routeCombo) == 0 {
 		return []int{}, errors.New("\nERROR: internal malfunction, \" calcAntGrouping \" function called " +
 			"with an input slice of routes with a length of zero")
 	}
 
+	routeLengths := make([]int, len(routeCombo))
+	for i, route := range routeCombo {
+		routeLengths[i] = routeTurns(route)
+	}
+	if !solver.Farm.HasStartAnts() {
+		return DistributeAnts(routeLengths, solver.Farm.TotalAntNbr), nil
+	}
+
 	antGrouping := make([]int, len(routeCombo))
-	index := 0
-	for i := 0; i < sys.TotalAntNbr; i++ {
-		index = findBestPath(routeCombo, antGrouping)
-		antGrouping[index]++
+	for _, start := range solver.Farm.Starts {
+		var indices, lengths []int
+		for i, route := range routeCombo {
+			if route[0] == start {
+				indices = append(indices, i)
+				lengths = append(lengths, routeLengths[i])
+			}
+		}
+		for k, ants := range DistributeAnts(lengths, start.Ants) {
+			antGrouping[indices[k]] = ants
+		}
 	}
 	return antGrouping, nil
 }
 
+/*
+missingStart returns the first start room with ants of its own (see sys.Farm.HasStartAnts) which none of the
+input routes leave, or nil if there is none.
+*/
+func (solver *Solver) missingStart(routeCombo [][]*sys.Room) *sys.Room {
+	for _, start := range solver.Farm.Starts {
+		found := start.Ants == 0
+		for _, route := range routeCombo {
+			found = found || route[0] == start
+		}
+		if !found {
+			return start
+		}
+	}
+	return nil
+}
+
 /*
 intersection is a function that takes two input slice of integers and returns a slice of integers containing
 the intersecting values of the two slices
@@ -326,6 +683,18 @@ func intersection(a, b []int) []int {
 	return result
 }
 
+/*
+routeIndices takes an input slice of routes and returns the indices of all of its routes (0, 1, 2 ...),
+for rating a set of routes as a whole with calculateRating.
+*/
+func routeIndices(setRoutes [][]*sys.Room) []int {
+	indices := make([]int, len(setRoutes))
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
+
 /*
 contains is a function that iterates over an input slice of integers and returns true if the integer element
 e is found in the slice, and false otherwise. It is used in the getNonConflictingCombinations function to
@@ -344,28 +713,51 @@ func contains(intSlice []int, e int) bool {
 getNonConflictingCombinations is a recursive function that takes a map of with routeKey (int) keys and a slice
 of the corresponding conflicting routeKeys ([]int) for that key, as well as a specified routeKey integer. It
 returns all combinations of routes that include the specified route (found with routeteKey input) and do not
-include any conflicting routes.
+include any conflicting routes, nor any combination rejected by the "fits" function (unless nil), along with
+its extensions. Once the context is done, no further combinations are generated.
 */
-func getNonConflictingCombinations(routeConflictMap map[int][]int, combination []int) [][]int {
+func getNonConflictingCombinations(ctx context.Context, routeConflictMap map[int][]int, combination []int,
+	fits func([]int) bool) [][]int {
 	// Initialize a slice to hold the combinations
 	var combinations [][]int
 	// Add the current combination as a valid combination
 	combinations = append(combinations, combination)
-	// Iterate over all routes in the map
-	for routeKey, conflicts := range routeConflictMap {
+	if ctx.Err() != nil {
+		return combinations
+	}
+	// Iterate over all routes in the map, in order of their keys
+	for _, routeKey := range conflictKeys(routeConflictMap) {
+		conflicts := routeConflictMap[routeKey]
 		// Check if the current route is not already in the combination and has no conflicts with the routes in the combination
 		if !contains(combination, routeKey) && len(intersection(conflicts, combination)) == 0 {
 			// If the current route is not in the combination and has no conflicts, we can add it to the combinations
-			newCombination := append(combination, routeKey)
+			// (copied, so that combinations never share the same underlying array)
+			newCombination := append(append(make([]int, 0, len(combination)+1), combination...), routeKey)
+			if fits != nil && !fits(newCombination) {
+				continue
+			}
 			combinations = append(combinations, newCombination)
 			// Recursively generate combinations for the new combination
-			subCombinations := getNonConflictingCombinations(routeConflictMap, newCombination)
+			subCombinations := getNonConflictingCombinations(ctx, routeConflictMap, newCombination, fits)
 			combinations = append(combinations, subCombinations...)
 		}
 	}
 	return combinations
 }
 
+/*
+conflictKeys returns the keys (route indices) of a route conflict map in ascending order, so that route
+combinations are always generated in the same order.
+*/
+func conflictKeys(routeConflictMap map[int][]int) []int {
+	keys := make([]int, 0, len(routeConflictMap))
+	for routeKey := range routeConflictMap {
+		keys = append(keys, routeKey)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
 /*
 compileRoute takes an input slice of slices of pointers to Room objects representing all routes routes found, as
 well as a slice of integers represemting the indices of chosen routes. The function then compiles a route combination
@@ -408,10 +800,13 @@ func compileRoute(allRoutes [][]*sys.Room, routeIndices []int) ([][]*sys.Room, e
 calculateRating is a function that takes a slice of slices of pointers to Room objects representing all
 routes, and a slice of integers representing indices of the routes to be considered. It returns a slice of
 integers representing the number of turns and number of ant moves for the selected route combination, as well
-as an error value. This error value is non-nil if any local function calls produce an error (e.g. sortRoutes,
-calcAntGrouping or maxInt) or if the input slice of route indices has a length of zero.
+as an error value. The number of turns is that of the last ant to finish, over all routes used (a route taking
+at least two turns). A combination leaving out a start room with ants of its own (see missingStart) is rated
+with the highest possible numbers of turns and moves. The error value is non-nil if any local function calls
+produce an error (e.g. sortRoutes, calcAntGrouping or maxInt) or if the input slice of route indices has a
+length of zero.
 */
-func calculateRating(allRoutes [][]*sys.Room, routeIndices []int) ([]int, error) {
+func (solver *Solver) calculateRating(allRoutes [][]*sys.Room, routeIndices []int) ([]int, error) {
 	if len(routeIndices) == 0 {
 		return []int{}, errors.New("\nERROR: internal malfunction, the function \" routeComboRating \" " +
 			"given zero-length slice of routes as input")
@@ -426,27 +821,62 @@ func calculateRating(allRoutes [][]*sys.Room, routeIndices []int) ([]int, error)
 	if err != nil {
 		return output, err
 	}
+	if solver.missingStart(routeCombo) != nil {
+		output[0], output[1] = math.MaxInt, math.MaxInt
+		return output, nil
+	}
 
 	// Assign ants to input route
-	antGrouping, err := calcAntGrouping(routeCombo)
+	antGrouping, err := solver.calcAntGrouping(routeCombo)
 	if err != nil {
 		return []int{}, err
 	}
 
 	// Calculate ratings for the route combination (routeCombo)
-	routeTurn, err = maxInt(len(routeCombo[0])-2, 1)
-	if err != nil {
-		return []int{}, err
-	}
-	nbrTurns := antGrouping[0] + routeTurn
+	nbrTurns := 0
 	nbrAntMoves := 0
 	for i, route := range routeCombo {
+		if antGrouping[i] == 0 {
+			continue
+		}
+		routeTurn, err = maxInt(routeTurns(route)-1, 1)
+		if err != nil {
+			return []int{}, err
+		}
+		nbrTurns, err = maxInt(nbrTurns, antGrouping[i]+routeTurn)
+		if err != nil {
+			return []int{}, err
+		}
 		nbrAntMoves += antGrouping[i] * (len(route) - 1)
 	}
 	output[0], output[1] = nbrTurns, nbrAntMoves
 	return output, nil
 }
 
+/*
+overCapacity returns true if more of the routes (given by their indices within the input slice of all routes)
+pass through any intermediate room holding several ants (see sys.Room.AntCapacity) than the room can hold.
+Rooms holding a single ant are left to checkRouteConflict.
+*/
+func overCapacity(allRoutes [][]*sys.Room, routeIndices []int) bool {
+	var routesThrough map[*sys.Room]int
+	for _, index := range routeIndices {
+		route := allRoutes[index]
+		for i := 1; i < len(route)-1; i++ {
+			if route[i].AntCapacity() > 1 {
+				if routesThrough == nil {
+					routesThrough = make(map[*sys.Room]int)
+				}
+				routesThrough[route[i]]++
+				if routesThrough[route[i]] > route[i].AntCapacity() {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 /*
 compareRatings compares two slices of integers and returns a boolean value and an error. The input slices,
 ratingToBeTested and ratingTestedAgainst, are expected to have a length of 2. The function compares the
@@ -478,186 +908,498 @@ func compareRatings(ratingToBeTested, ratingTestedAgainst []int) (bool, error) {
 }
 
 /*
-findBestRouteCombo is a piece of RECURSIVE BEAUTY and takes a map of routes as input, where the key is a route ID and the
-value is a slice of all routes that conflict with the route specified in the key. It also takes a function calculateRating
-that takes a slice of integers representing a combination of routes as input and returns a slice of integer ratings for
-that combination. The function recursively iterates over all routes in the map, generating all valid routes combinations
-(not conflicting) and calculates the rating for each combination using the calculateRating function, maintaining variables
-for the best rated combination and best rating thus far. Finally, the function returns the best rated combination along with
-an error value, which is non-nil if any local function calls (calculateRating, compareRating and compileRoute) return an error.
+combinationLess is the tie-break between two route combinations with the same rating: the combinations
+are compared as sets, with their route indices in ascending order, and the first combination with a
+smaller index (i.e. a shorter route, as all routes are sorted by length) wins. This makes the chosen
+combination independent of the order in which the combinations are rated.
 */
-func findBestRouteCombo(allRoutes [][]*sys.Room, routeConflictMap map[int][]int,
-	calculateRating func([][]*sys.Room, []int) ([]int, error)) ([][]*sys.Room, error) {
-	// Initialise working and output variables
-	var err error
-	var bestRouteCombo [][]*sys.Room
-	var bestCombination []int
-	var bestRating []int
-	var rating []int
-	var better bool
+func combinationLess(combination1, combination2 []int) bool {
+	for i := 0; i < len(combination1) && i < len(combination2); i++ {
+		if combination1[i] != combination2[i] {
+			return combination1[i] < combination2[i]
+		}
+	}
+	return len(combination1) < len(combination2)
+}
 
-	// Iterate over routes in conflict map
-	for routeKey := range routeConflictMap {
-		// Check if route has conflicts
-		// If the current route has conflicts, check all combinations that do not include any conflicting routes
-		combinations := getNonConflictingCombinations(routeConflictMap, []int{routeKey})
-		for _, combination := range combinations {
-			// Check the rating of the current combination
-			rating, err = calculateRating(allRoutes, combination)
-			if err != nil {
-				return bestRouteCombo, err
-			}
+/*
+ratedCombination is a route combination (its route indices, in ascending order) along with its rating.
+*/
+type ratedCombination struct {
+	combination []int
+	rating      []int
+}
 
-			better, err = compareRatings(rating, bestRating)
-			if err != nil {
-				return bestRouteCombo, err
-			}
-			// If the current combination is found to be better, update the best rating and best rated combination variables
-			if better {
-				bestRating = rating
-				bestCombination = combination
+/*
+better returns true if the ratedCombination is better than the other one: a better rating (see
+compareRatings), or an equal rating and a lower combination (see combinationLess). Any combination is
+better than an empty one. A non-nil error is returned if compareRatings returns an error.
+*/
+func (rated ratedCombination) better(other ratedCombination) (bool, error) {
+	if other.combination == nil {
+		return true, nil
+	}
+	better, err := compareRatings(rated.rating, other.rating)
+	if err != nil || better {
+		return better, err
+	}
+	worse, err := compareRatings(other.rating, rated.rating)
+	if err != nil || worse {
+		return false, err
+	}
+	return combinationLess(rated.combination, other.combination), nil
+}
+
+/*
+rateCombinations generates (with getNonConflictingCombinations, and the "fits" function if not nil) and
+rates (with calculateRating) all combinations starting with the given route, and returns the best one, or
+the best one so far once the context is done (if any combination has been rated already). A non-nil error is
+returned if any of the local function calls return an error.
+*/
+func rateCombinations(ctx context.Context, allRoutes [][]*sys.Room, routeConflictMap map[int][]int, routeKey int,
+	fits func([]int) bool, calculateRating func([][]*sys.Room, []int) ([]int, error),
+	best ratedCombination) (ratedCombination, error) {
+	if fits != nil && !fits([]int{routeKey}) {
+		return best, nil
+	}
+	for _, combination := range getNonConflictingCombinations(ctx, routeConflictMap, []int{routeKey}, fits) {
+		if best.combination != nil && ctx.Err() != nil {
+			break
+		}
+		// Check the rating of the current combination
+		rating, err := calculateRating(allRoutes, combination)
+		if err != nil {
+			return best, err
+		}
+		rated := ratedCombination{combination: append([]int{}, combination...), rating: rating}
+		sort.Ints(rated.combination)
+		// If the current combination is found to be better, update the best rated combination
+		better, err := rated.better(best)
+		if err != nil {
+			return best, err
+		} else if better {
+			best = rated
+		}
+	}
+	return best, nil
+}
+
+/*
+findBestRouteCombo is a piece of RECURSIVE BEAUTY and takes a map of routes as input, where the key is a route ID and
+the value is a slice of all routes that conflict with the route specified in the key. It also takes a function
+calculateRating that takes a slice of integers representing a combination of routes as input and returns a slice of
+integer ratings for that combination, and a function "fits" (or nil) rejecting any further combinations (see
+capacityCheck). The function recursively iterates over all routes in the map, generating all valid routes combinations
+(not conflicting) and calculates the rating for each combination using the calculateRating function, maintaining
+variables for the best rated combination and best rating thus far. The routes of the map are shared out between a pool
+of "workers" goroutines (at least one), each of which rates the combinations starting with its routes, and as ties
+between equally rated combinations are broken by combinationLess, the same combination is returned for any number of
+workers. Finally, the function returns the best rated combination along with an error value, which is non-nil if any
+local function calls (calculateRating, compareRating and compileRoute) return an error. Once the context is done, the
+best rated combination found so far is returned (at least one combination is always rated).
+*/
+func findBestRouteCombo(ctx context.Context, allRoutes [][]*sys.Room, routeConflictMap map[int][]int,
+	fits func([]int) bool, calculateRating func([][]*sys.Room, []int) ([]int, error),
+	workers int) ([][]*sys.Room, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	routeKeys := make(chan int)
+	results := make(chan ratedCombination, workers)
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			var best ratedCombination
+			var err error
+			for routeKey := range routeKeys {
+				if err == nil {
+					best, err = rateCombinations(ctx, allRoutes, routeConflictMap, routeKey, fits, calculateRating, best)
+				}
 			}
+			results <- best
+			errs <- err
+		}()
+	}
+	// Iterate over routes in conflict map
+	for _, routeKey := range conflictKeys(routeConflictMap) {
+		routeKeys <- routeKey
+	}
+	close(routeKeys)
+
+	// Compare the best combination of each worker
+	var best ratedCombination
+	var err error
+	for w := 0; w < workers; w++ {
+		rated := <-results
+		if errWorker := <-errs; errWorker != nil {
+			err = errWorker
+		} else if rated.combination == nil {
+			continue // Worker without routes
+		} else if better, errBetter := rated.better(best); errBetter != nil {
+			err = errBetter
+		} else if better {
+			best = rated
 		}
 	}
-	bestRouteCombo, err = compileRoute(allRoutes, bestCombination)
 	if err != nil {
-		return bestRouteCombo, err
+		return nil, err
 	}
-	return bestRouteCombo, nil
+	return compileRoute(allRoutes, best.combination)
 }
 
 /*
-fillNextValues loops through the global Routes variable, which is assumed to have already been filtered
-and ordered. Each room in a path (aside from the start and end room) is then assigned its Next element
-value, which is a pointer to the next room on the route. A non-nil error is returned in the event that
-the room (not start or end) already has a Next value, meaning it already features in another route (conflict).
+checkRouteRooms loops through the Routes of the Solver, which is assumed to have already been filtered
+and ordered. A non-nil error is returned in the event that a room (aside from the start and end room)
+features in more than one route (conflict). The rooms themselves are left untouched (their Next values
+are not assigned), as the Farm may be shared by several Solvers. Where rooms may feature in several
+routes (see sharesRooms), no check is made.
 */
-func fillNextValues() error {
-	for i := 0; i < len(Routes); i++ {
-		for j := 1; j < len(Routes[i])-1; j++ { // Skip start and end rooms of each route
-			if Routes[i][j].Next != nil {
-				return errors.New("\nERROR: internal malfunction, the function \" fillNextValues \" trying to " +
-					"fill Next value for a Room which has an existing Next value (exists on multiple routes)")
+func (solver *Solver) checkRouteRooms() error {
+	if solver.sharesRooms() {
+		return nil
+	}
+	used := make(map[*sys.Room]bool)
+	for i := 0; i < len(solver.Routes); i++ {
+		for j := 1; j < len(solver.Routes[i])-1; j++ { // Skip start and end rooms of each route
+			if used[solver.Routes[i][j]] {
+				return errors.New("\nERROR: internal malfunction, the function \" checkRouteRooms \" found a " +
+					"Room which exists on multiple routes, with name: " + solver.Routes[i][j].Name)
 			}
-			Routes[i][j].Next = Routes[i][j+1]
+			used[solver.Routes[i][j]] = true
 		}
 	}
 	return nil
 }
 
 /*
-filterRoutes acts on the Routes global variable ([][]*Room), removing the longer route of
-conflicting route pairs, and ordering the global Routes variable in ascending order of
-length. A non-nil error is returned if an internal error is encountered in any of the
-above operations.
+repeatRoutes returns the input routes (in ascending order of length), with every route whose intermediate rooms
+all hold several ants (see sys.Room.AntCapacity) repeated as many times as its smallest room capacity, so that
+the same rooms can carry several ants per turn. Routes without intermediate rooms are never repeated. The
+copies of a route follow each other, and are numbered (from 0 for the route itself) in the second returned
+slice, by index of the returned routes.
+*/
+func repeatRoutes(allRoutes [][]*sys.Room) ([][]*sys.Room, []int) {
+	repeated := make([][]*sys.Room, 0, len(allRoutes))
+	copies := make([]int, 0, len(allRoutes))
+	for _, route := range allRoutes {
+		times := 1
+		for i := 1; i < len(route)-1; i++ {
+			if i == 1 || route[i].AntCapacity() < times {
+				times = route[i].AntCapacity()
+			}
+		}
+		for n := 0; n < times; n++ {
+			repeated = append(repeated, route)
+			copies = append(copies, n)
+		}
+	}
+	return repeated, copies
+}
+
+/*
+capacityCheck returns the "fits" function of findBestRouteCombo for the input routes and their copy numbers
+(see repeatRoutes) under sys.RulesRooms, accepting a route combination only if no room is used by more routes
+than it can hold ants (see overCapacity). As the copies of a repeated route are interchangeable, a copy is
+only accepted alongside the previous copy, so that each set of routes is only rated once. nil is returned if
+no room of the Farm can hold more than one ant, or under sys.RulesTunnels.
+*/
+func (solver *Solver) capacityCheck(allRoutes [][]*sys.Room, copies []int) func([]int) bool {
+	if solver.Rules == sys.RulesTunnels || !solver.Farm.HasCapacities() {
+		return nil
+	}
+	return func(combination []int) bool {
+		last := combination[len(combination)-1]
+		if copies[last] > 0 && !contains(combination, last-1) {
+			return false // Copy of a repeated route, without the previous copy
+		}
+		return !overCapacity(allRoutes, combination)
+	}
+}
+
+/*
+filterRoutes acts on the Routes of the Solver ([][]*Room), removing the longer route of
+conflicting route pairs (sharing rooms, or sharing tunnels under sys.RulesTunnels, with routes through
+rooms holding several ants first repeated by repeatRoutes), and ordering the Routes in ascending order
+of length. The search for the best combination of routes stops early once the context is done. A non-nil
+error is returned if an internal error is encountered in any of the above operations.
 */
-func filterRoutes(allRoutes [][]*sys.Room) ([][]*sys.Room, error) {
+func (solver *Solver) filterRoutes(ctx context.Context, allRoutes [][]*sys.Room) ([][]*sys.Room, error) {
 	if len(allRoutes) == 0 {
 		return allRoutes, errors.New("\nERROR: internal malfuntion, the \" filterRoutes \" function called" +
-			"while no routes recorded in global Routes variable")
+			"while no routes recorded in Routes")
 	}
 
 	// Find optimal combination of valid, non-duplicate routes
-	routeConflictMap, err := createConflictMap(allRoutes)
+	checkConflict := checkRouteConflict
+	copies := make([]int, len(allRoutes))
+	if solver.Rules == sys.RulesTunnels {
+		checkConflict = checkTunnelConflict
+	} else {
+		allRoutes, copies = repeatRoutes(allRoutes)
+	}
+	routeConflictMap, err := createConflictMap(allRoutes, checkConflict)
 	if err != nil {
 		return allRoutes, err
 	}
-	Routes, err = findBestRouteCombo(allRoutes, routeConflictMap, calculateRating)
+	solver.Routes, err = findBestRouteCombo(ctx, allRoutes, routeConflictMap, solver.capacityCheck(allRoutes, copies),
+		solver.calculateRating, solver.Workers)
 	if err != nil {
 		return allRoutes, err
 	}
+	if start := solver.missingStart(solver.Routes); start != nil {
+		return allRoutes, errors.New("\nERROR: invalid data format, no valid routes between start room \" " +
+			start.Name + " \" and end rooms could be found, which do not conflict with the routes of the other " +
+			"start rooms")
+	}
 
-	// Assign Next values (*.sys.Room) for all rooms on valid routes
-	err = fillNextValues()
+	// Check that no room features in several routes
+	err = solver.checkRouteRooms()
 	if err != nil {
 		return allRoutes, err
 	}
-	return Routes, nil
+	return solver.Routes, nil
 }
 
 /*
-moveANT takes an input route ([]*sys.Room) as well as the index of a room on the route. An ant is then
-moved from this room to the next room on the route. A non-nil error is returned if an ant is not present
-in the specified room, or an ant is already present in the next room in the chain.
+sharesRooms returns true if a room may feature in several routes at once: under sys.RulesTunnels, or if
+any room of the Farm can hold more than one ant (see sys.Room.AntCapacity).
 */
-func moveAnt(route []*sys.Room, index int) error {
+func (solver *Solver) sharesRooms() bool {
+	return solver.Rules == sys.RulesTunnels || solver.Farm.HasCapacities()
+}
+
+/*
+trackRouteAnts prepares the Solver for moving ants along its Routes: each route keeps track of the ant at
+each of its positions (0 if none), rather than the rooms themselves (see sys.Room.AntID), as the Farm may be
+shared by several Solvers, and a room may feature in several routes (see sharesRooms). If rooms hold several
+ants under sys.RulesRooms, the ants in each room are also counted against its capacity (see countMove). Each
+route has one position per turn taken to follow it (see routeTurns), plus one for its start room: an ant moves
+on by one position every turn, and is in a room only at the positions of the routeSlots of its route which
+are not -1 (in transit through a weighted tunnel otherwise). Without weighted tunnels, the positions are the
+indices of the rooms on the route.
+*/
+func (solver *Solver) trackRouteAnts() {
+	solver.routeAnts = make([][]int, len(solver.Routes))
+	for i, route := range solver.Routes {
+		solver.routeAnts[i] = make([]int, routeTurns(route)+1)
+	}
+	solver.routeSlots, solver.roomAnts = nil, nil
+	if solver.Farm.HasWeights() {
+		solver.routeSlots = make([][]int, len(solver.Routes))
+		for i, route := range solver.Routes {
+			solver.routeSlots[i] = append(make([]int, 0, len(solver.routeAnts[i])), 0)
+			for j := 1; j < len(route); j++ {
+				for turn := 1; turn < route[j-1].Weight(route[j]); turn++ {
+					solver.routeSlots[i] = append(solver.routeSlots[i], -1)
+				}
+				solver.routeSlots[i] = append(solver.routeSlots[i], j)
+			}
+		}
+	}
+	if solver.Rules != sys.RulesTunnels && solver.Farm.HasCapacities() {
+		solver.roomAnts = make(map[*sys.Room]int)
+	}
+}
+
+/*
+roomAt returns the index on the route at the given index of the Routes of the Solver of the room at the
+given position (see trackRouteAnts), or -1 if an ant at this position is in transit through a tunnel.
+*/
+func (solver *Solver) roomAt(routeIndex, position int) int {
+	if solver.routeSlots == nil {
+		return position
+	}
+	return solver.routeSlots[routeIndex][position]
+}
+
+/*
+describePosition returns the room (or the tunnel, if in transit) at the given position of the route at the
+given index of the Routes of the Solver, for error messages.
+*/
+func (solver *Solver) describePosition(routeIndex, position int) string {
+	route := solver.Routes[routeIndex]
+	for i := position; i < len(solver.routeAnts[routeIndex]); i++ {
+		if index := solver.roomAt(routeIndex, i); index >= 0 && i == position {
+			return "room, with name: " + route[index].Name
+		} else if index >= 0 {
+			return "tunnel to room, with name: " + route[index].Name
+		}
+	}
+	return "position: " + strconv.Itoa(position)
+}
+
+/*
+countMove moves an ant from one room to the next in the count of ants in each room, if counted (see
+trackRouteAnts), with a nil room for an ant leaving or entering a weighted tunnel (ants in transit are not
+in any room). A non-nil error is returned if the next room is an intermediate room which already holds as
+many ants as its capacity.
+*/
+func (solver *Solver) countMove(from, to *sys.Room) error {
+	if solver.roomAnts == nil {
+		return nil
+	}
+	if to != nil && to.Class == "intermediate" {
+		if solver.roomAnts[to] >= to.AntCapacity() {
+			return errors.New("\nERROR: internal malfunction, room \" " + to.Name + " \" already holds " +
+				strconv.Itoa(to.AntCapacity()) + " ant(s), its capacity")
+		}
+		solver.roomAnts[to]++
+	}
+	if from != nil && from.Class == "intermediate" {
+		solver.roomAnts[from]--
+	}
+	return nil
+}
+
+/*
+useTunnel records that the tunnel between the two input rooms has been used in the current turn, under
+sys.RulesTunnels. A non-nil error is returned if the tunnel (in either direction) was already used.
+*/
+func (solver *Solver) useTunnel(from, to *sys.Room) error {
+	if solver.Rules != sys.RulesTunnels {
+		return nil
+	}
+	if solver.usedTunnels == nil {
+		solver.usedTunnels = make(map[[2]*sys.Room]bool)
+	}
+	if solver.usedTunnels[[2]*sys.Room{from, to}] || solver.usedTunnels[[2]*sys.Room{to, from}] {
+		return errors.New("\nERROR: internal malfunction, tunnel between rooms \" " + from.Name + " \" and \" " +
+			to.Name + " \" used more than once in the same turn")
+	}
+	solver.usedTunnels[[2]*sys.Room{from, to}] = true
+	return nil
+}
+
+/*
+moveANT takes the index of a route within the Routes of the Solver, as well as a position on the route (the
+index of a room, unless tunnels are weighted, see trackRouteAnts). An ant is then moved from this position to
+the next position on the route. An ant leaving a room enters the tunnel to the next room (which, under
+sys.RulesTunnels, counts as the use of the tunnel in the current turn), and its move is only recorded once
+it arrives in the next room, which may be several turns later if the tunnel is weighted. A non-nil error is
+returned if an ant is not present at the specified position, or an ant is already present at the next position
+(or the next room is full, see countMove), or (under sys.RulesTunnels) the tunnel was already used in the
+current turn.
+*/
+func (solver *Solver) moveAnt(routeIndex, position int) error {
+	route, ants := solver.Routes[routeIndex], solver.routeAnts[routeIndex]
+	// Room left (if not in transit) and room reached (if any), by index on the route
+	left, reached := solver.roomAt(routeIndex, position), solver.roomAt(routeIndex, position+1)
 	// Check if room has ant
-	if route[index].AntID == 0 {
+	if ants[position] == 0 {
 		return errors.New("\nERROR: internal malfunction, input to function \" moveAnt \" is invalid" +
-			"\nno ant present in specified room, with name: " + route[index].Name)
-	} else if route[index+1].AntID != 0 {
+			"\nno ant present in specified " + solver.describePosition(routeIndex, position))
+	} else if ants[position+1] != 0 {
 		return errors.New("\nERROR: internal malfunction, input to function \" moveAnt \" is invalid" +
-			"\nant already present in next room for route, with name: " + route[index+1].Name)
+			"\nant already present in next " + solver.describePosition(routeIndex, position+1) + " for route")
+	}
+	var from, to *sys.Room
+	if left >= 0 {
+		from = route[left]
+		errTunnel := solver.useTunnel(from, route[left+1])
+		if errTunnel != nil {
+			return errTunnel
+		}
+	}
+	if reached >= 0 {
+		to = route[reached]
+	}
+	errCount := solver.countMove(from, to)
+	if errCount != nil {
+		return errCount
 	}
 
-	// Write to global CurrentTurnStr variable (string to be printed out)
-	if len(CurrentTurnStr) == 0 { // If first entry, don't begin with space
-		CurrentTurnStr = CurrentTurnStr + "L" + strconv.Itoa(route[index].AntID) + "-" + route[index+1].Name
-	} else {
-		CurrentTurnStr = CurrentTurnStr + " L" + strconv.Itoa(route[index].AntID) + "-" + route[index+1].Name
+	// Write to CurrentTurn (moves to be printed out), once the ant reaches the next room
+	if to != nil {
+		solver.CurrentTurn = append(solver.CurrentTurn, Move{Ant: ants[position], From: route[reached-1].Name, To: to.Name})
 	}
 
 	// Move ant to / from rooms
-	if route[index+1].Class == "end" {
-		TotalAntsFinished++
-		route[index].AntID = 0
+	if to != nil && to.Class == "end" {
+		solver.TotalAntsFinished++
+		ants[position] = 0
 	} else {
-		route[index+1].AntID, route[index].AntID = route[index].AntID, 0
+		ants[position+1], ants[position] = ants[position], 0
 	}
 	return nil
 }
 
 /*
-moveNew takes no input, scans the global Routes variable and places an ant on the first room after the start room
-for every route where the corresponding ant counter > 0 (from global AntGrouping variable). A non-nil error
-is returned if the first room of any respective route still has an ant in it (conflict).
+numberStartAnts prepares the numbering of the ants by start room, if the number of ants of each start room
+is given (see sys.Farm.HasStartAnts): the ants of the first start room are numbered first, then those of
+the second start room, and so on, in the order of the Starts of the Farm.
 */
-func moveNewAnts() error {
-	for i, route := range Routes {
-		if AntGrouping[i] != 0 && AntID <= sys.TotalAntNbr {
-			if route[1].AntID != 0 {
+func (solver *Solver) numberStartAnts() {
+	solver.startAntIDs = nil
+	if !solver.Farm.HasStartAnts() {
+		return
+	}
+	solver.startAntIDs = make(map[*sys.Room]int, len(solver.Farm.Starts))
+	ant := 1
+	for _, start := range solver.Farm.Starts {
+		solver.startAntIDs[start] = ant
+		ant += start.Ants
+	}
+}
+
+/*
+moveNew takes no input, scans the Routes of the Solver and moves an ant from the start room towards the first
+room after the start room (see moveAnt) for every route where the corresponding ant counter > 0 (from the
+AntGrouping of the Solver). Ants are numbered in the order they leave, or, if start rooms are given their
+own ants (see numberStartAnts), in the order of their start rooms. A non-nil error is returned if the first
+position of any respective route still has an ant in it, or the first room is full (conflict).
+*/
+func (solver *Solver) moveNewAnts() error {
+	for i, route := range solver.Routes {
+		if solver.AntGrouping[i] != 0 && solver.AntID <= solver.Farm.TotalAntNbr {
+			if solver.routeAnts[i][1] != 0 {
 				return errors.New("\nERROR: internal malfunction, input to function \" moveNew \" is invalid" +
 					"\nant already present in route's first room, with name: " + route[1].Name)
 			}
 
-			// Write to global CurrentTurnStr variable (string to be printed out)
-			if len(CurrentTurnStr) == 0 { // If first entry, don't begin with space
-				CurrentTurnStr = CurrentTurnStr + "L" + strconv.Itoa(AntID) + "-" + route[1].Name
+			// Place ant in the start room of the route, and move it on
+			if solver.startAntIDs != nil {
+				solver.routeAnts[i][0] = solver.startAntIDs[route[0]]
+				solver.startAntIDs[route[0]]++
 			} else {
-				CurrentTurnStr = CurrentTurnStr + " L" + strconv.Itoa(AntID) + "-" + route[1].Name
+				solver.routeAnts[i][0] = solver.AntID
 			}
-
-			// Place ant in 1st room of route
-			if Routes[i][1].Class == "end" {
-				TotalAntsFinished++ // If start and end room directly connected
-			} else {
-				Routes[i][1].AntID = AntID
+			errMoveAnt := solver.moveAnt(i, 0)
+			if errMoveAnt != nil {
+				return errMoveAnt
 			}
 
-			AntGrouping[i]--
-			// Update global counters
-			if AntID == sys.TotalAntNbr {
+			solver.AntGrouping[i]--
+			// Update counters
+			if solver.AntID == solver.Farm.TotalAntNbr {
 				break
 			}
-			AntID++
+			solver.AntID++
 		}
 	}
 	return nil
 }
 
 /*
-moveExisting takes no input, but scans the global Routes variable for those rooms where ants are already
+moveExisting takes no input, but scans the Routes of the Solver for those rooms where ants are already
 placed. These ants are then moved to the next room on their respective routes, and these moves are
-recorded to the global currentTurnStr variable. A non-nil error is returned if any internal process
-encounters an error during the function's execution.
+recorded to the CurrentTurn of the Solver, which is emptied first. Unless RecordTurns is true (in which case the
+moves of each turn are kept in Turns), the same CurrentTurn is reused for every turn. A non-nil error is returned if
+any internal process encounters an error during the function's execution.
 */
-func moveExistingAnts() error {
-	CurrentTurnStr = ""
-	for i, route := range Routes {
+func (solver *Solver) moveExistingAnts() error {
+	if solver.RecordTurns {
+		solver.CurrentTurn = nil
+	} else {
+		solver.CurrentTurn = solver.CurrentTurn[:0]
+	}
+	for tunnel := range solver.usedTunnels {
+		delete(solver.usedTunnels, tunnel)
+	}
+	for i := range solver.Routes {
 		// Scan each respective route backwards to ensure that space is opened for forward movement of ants
-		for j := len(route) - 2; j >= 1; j-- {
-			if Routes[i][j].AntID != 0 {
-				errMoveAnt := moveAnt(Routes[i], j)
+		for j := len(solver.routeAnts[i]) - 2; j >= 1; j-- {
+			if solver.routeAnts[i][j] != 0 {
+				errMoveAnt := solver.moveAnt(i, j)
 				if errMoveAnt != nil {
 					return errMoveAnt
 				}
@@ -668,57 +1410,221 @@ func moveExistingAnts() error {
 }
 
 /*
-executeMoves takes no input and operates on the global Routes variable, writing to individual rooms
-when ants have moved in / out. It calls the local functions moveExisting and moveNew which write the
-ant movements to the global CurrentTurnStr variable. This string variable is printed out to the terminal
-after each successive loop within the function. A non-nil error is returned if any of the local function
-calls result in an error.
+writeTurn writes the moves of the CurrentTurn of the Solver to the bufio.Writer as a single line
+(" L<ant>-<room> " moves, separated by spaces), without building the line in memory. A non-nil error is
+returned if writing fails.
 */
-func executeMoves() error {
+func (solver *Solver) writeTurn(writer *bufio.Writer) error {
+	var number [20]byte
+	for i, move := range solver.CurrentTurn {
+		if i > 0 {
+			writer.WriteByte(' ')
+		}
+		writer.WriteByte('L')
+		writer.Write(strconv.AppendInt(number[:0], int64(move.Ant), 10))
+		writer.WriteByte('-')
+		writer.WriteString(move.To)
+	}
+	return writer.WriteByte('\n') // Errors of the bufio.Writer persist until it is flushed
+}
+
+/*
+executeMoves takes no input and operates on the Routes of the Solver, keeping track of the ants in individual
+rooms (see trackRouteAnts) as they move in / out. It calls Step (moveExisting and moveNew) for every turn,
+which writes the ant movements to the CurrentTurn of the Solver. These moves are streamed to the Output of the
+Solver (through a buffer) after each successive loop within the function, or only the number of turns once all
+ants have finished if SummaryOnly is true, and the moves of the turn are appended to the Turns of the Solver
+if RecordTurns is true. The moves are preceded by a blank line if EchoInput is true, separating them from the
+input file contents. The number of turns is kept in the TurnCount of the Solver. A non-nil error is returned
+if any of the local function calls result in an error, or if writing fails.
+*/
+func (solver *Solver) executeMoves() error {
 	var errExecuteMoves error
-	fmt.Println()
-	for TotalAntsFinished < sys.TotalAntNbr {
-		errExecuteMoves = moveExistingAnts()
+	writer := bufio.NewWriter(solver.Output)
+	if solver.EchoInput {
+		// Blank line separating the moves from the input file contents
+		writer.WriteByte('\n')
+	}
+	for solver.TotalAntsFinished < solver.Farm.TotalAntNbr {
+		_, errExecuteMoves = solver.Step()
 		if errExecuteMoves != nil {
 			return errExecuteMoves
 		}
-		errExecuteMoves = moveNewAnts()
-		if errExecuteMoves != nil {
-			return errExecuteMoves
+		if !solver.SummaryOnly {
+			errExecuteMoves = solver.writeTurn(writer)
+			if errExecuteMoves != nil {
+				return errExecuteMoves
+			}
 		}
-		fmt.Println(CurrentTurnStr)
 	}
-	fmt.Println()
-	return nil
+	if solver.SummaryOnly {
+		writer.WriteString("turns: " + strconv.Itoa(solver.TurnCount) + "\n")
+	}
+	writer.WriteByte('\n')
+	return writer.Flush()
 }
 
 /*
-Run is a global function within the lem-in/routing package which calls several local functions
-to perform a network route analysis, filtering, and ant-routeing task. It operates on the global
-variables "Routes" ([][]*sys.Room) and "AntGrouping" ([]int), printing out the results of each turn
-(relative ant movements) to the terminal, until completion where all ants have been successfully
-routed from the start room to end room. A non-nil error is returned if any of the local functions
-encounter an error during their execution.
+Step moves the ants of the Solver by a single turn (see moveExisting and moveNew), once Prepare has been
+called, leaving the moves of the turn in the CurrentTurn of the Solver (and appending them to its Turns if
+RecordTurns is true). It returns false, without moving any ant, if all ants have already finished, and a
+non-nil error if moving the ants fails.
 */
-func Run() error {
-	allRoutes, err := runningDFS()
+func (solver *Solver) Step() (bool, error) {
+	if solver.TotalAntsFinished >= solver.Farm.TotalAntNbr {
+		return false, nil
+	}
+	errStep := solver.moveExistingAnts()
+	if errStep != nil {
+		return true, errStep
+	}
+	errStep = solver.moveNewAnts()
+	if errStep != nil {
+		return true, errStep
+	}
+	solver.TurnCount++
+	if solver.RecordTurns {
+		solver.Turns = append(solver.Turns, solver.CurrentTurn)
+	}
+	return true, nil
+}
+
+/*
+NewSolver returns a Solver for the input sys.Farm, with the default options: the AlgorithmDFS
+route-finding algorithm (finding at most DefaultMaxPaths paths, rated by a single worker), the
+sys.RulesRooms rule set, echoing of the input file contents, and printing to the standard output.
+*/
+func NewSolver(farm *sys.Farm) *Solver {
+	return &Solver{
+		Farm:      farm,
+		Algorithm: AlgorithmDFS,
+		Rules:     sys.RulesRooms,
+		MaxPaths:  DefaultMaxPaths,
+		Workers:   1,
+		EchoInput: true,
+		Output:    os.Stdout,
+		AntID:     1,
+	}
+}
+
+/*
+Run is a method of the Solver which calls several local functions to perform a network route analysis,
+filtering, and ant-routeing task on its Farm. It writes to the Solver's "Routes" ([][]*sys.Room),
+"AntGrouping" / "InitialGrouping" ([]int) and "Rating" ([]int) fields (see Prepare), printing out the results
+of each turn (relative ant movements) to the Solver's Output (or only the number of turns, if SummaryOnly is
+true), until completion where all ants have been successfully routed from the start room(s) to the end
+room(s). Unless EchoInput is false, the input file contents are printed before the moves. A non-nil error is
+returned if any of the local functions encounter an error during their execution.
+*/
+func (solver *Solver) Run(ctx context.Context) error {
+	err := solver.Prepare(ctx)
 	if err != nil {
 		return err
 	}
 
-	Routes, err = filterRoutes(allRoutes)
+	solver.stage(StageSimulate)
+	if solver.EchoInput {
+		writer := bufio.NewWriter(solver.Output)
+		err = solver.Farm.PrintFileContents(writer)
+		if err != nil {
+			return err
+		}
+		err = writer.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = solver.executeMoves()
 	if err != nil {
 		return err
 	}
+	return nil
+}
 
-	AntGrouping, err = calcAntGrouping(Routes)
+/*
+Prepare finds and selects the Routes of the Solver, shares the ants between them (AntGrouping and
+InitialGrouping) and rates them (Rating), after which the ants are ready to be moved turn by turn (see Step),
+from the start room(s) again if the Solver was run before (see resetAnts). Routes are found with the algorithm
+named by the Solver's Algorithm field (AlgorithmDFS or AlgorithmFlow), and ants are moved under the rule set
+named by its Rules field (sys.RulesRooms, one ant per intermediate room, or sys.RulesTunnels, one ant per
+tunnel per turn, with rooms shared freely). The route search stops early once the context is done (cancelled
+or expired), in which case the best routes found so far are used and Interrupted is set to true, as they may
+be sub-optimal (as it is if the depth-first search is capped by MaxPaths, see runningDFS). A non-nil error is
+returned if any of the local functions encounter an error during their execution, or if the rule set is
+unknown.
+*/
+func (solver *Solver) Prepare(ctx context.Context) error {
+	var err error
+	if solver.Rules == "" {
+		solver.Rules = sys.RulesRooms
+	} else if solver.Rules != sys.RulesRooms && solver.Rules != sys.RulesTunnels {
+		return errors.New("\nERROR: internal malfunction, unknown rule set \" " + solver.Rules + " \" " +
+			"\nexpected \" " + sys.RulesRooms + " \" or \" " + sys.RulesTunnels + " \"")
+	}
+	solver.Interrupted = false
+	solver.resetAnts()
+	solver.stage(StageSearch)
+	switch solver.Algorithm {
+	case AlgorithmDFS:
+		var allRoutes [][]*sys.Room
+		allRoutes, err = solver.runningDFS(ctx)
+		if err != nil {
+			return err
+		}
+		solver.stage(StageSelect)
+		solver.Routes, err = solver.filterRoutes(ctx, allRoutes)
+	case AlgorithmFlow:
+		solver.Routes, err = solver.filterFlowRoutes(ctx)
+		solver.stage(StageSelect)
+	default:
+		err = errors.New("\nERROR: internal malfunction, unknown routing algorithm \" " + solver.Algorithm + " \" " +
+			"\nexpected \" " + AlgorithmDFS + " \" or \" " + AlgorithmFlow + " \"")
+	}
 	if err != nil {
 		return err
 	}
+	solver.Interrupted = solver.Interrupted || ctx.Err() != nil
 
-	err = executeMoves()
+	solver.AntGrouping, err = solver.calcAntGrouping(solver.Routes)
 	if err != nil {
 		return err
 	}
+	solver.InitialGrouping = append([]int{}, solver.AntGrouping...)
+	solver.Rating, err = solver.calculateRating(solver.Routes, routeIndices(solver.Routes))
+	if err != nil {
+		return err
+	}
+
+	solver.trackRouteAnts()
+	solver.numberStartAnts()
 	return nil
 }
+
+/*
+resetAnts clears the counters and the recorded moves of the Solver used for moving ants, so that the same
+Solver can be prepared again (e.g. after its Farm has been edited, see sys.Farm.AddLink). The ants on the
+routes themselves are cleared by trackRouteAnts.
+*/
+func (solver *Solver) resetAnts() {
+	solver.AntID, solver.TotalAntsFinished, solver.TurnCount = 1, 0, 0
+	solver.CurrentTurn, solver.Turns = nil, nil
+}
+
+/*
+stage calls the OnStage function of the Solver (if any) with the name of the stage Run is starting.
+*/
+func (solver *Solver) stage(name string) {
+	if solver.OnStage != nil {
+		solver.OnStage(name)
+	}
+}
+
+/*
+Run is a global function within the lem-in/routing package, and a thin wrapper which solves the input
+sys.Farm with a new Solver using the default options (see NewSolver), stopping the route search early
+once the context is done. A non-nil error is returned if the Solver encounters an error during its execution.
+*/
+func Run(ctx context.Context, farm *sys.Farm) error {
+	return NewSolver(farm).Run(ctx)
+}
@@ -1,41 +1,126 @@
 package routing
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"lem-in/generate"
 	"lem-in/sys"
+	"math/rand"
 	"reflect"
+	"sort"
+	"sync"
 	"testing"
 )
 
 func TestFindByName(t *testing.T) {
+	farm := &sys.Farm{}
+	solver := NewSolver(farm)
 	// Establish test variables / struct
-	sys.Network = []sys.Room{{Name: "room1"}, {Name: "room2"}, {Name: "room3"},
+	farm.Network = []sys.Room{{Name: "room1"}, {Name: "room2"}, {Name: "room3"},
 		{Name: "room4"}, {Name: "room5"}, {Name: "room6"}}
 
 	// Test valid input
-	result := findByName("room4")
+	result := solver.findByName("room4")
 	if result == nil {
 		t.Errorf("\nfunction findByName returning nil for valid input")
-	} else if result.Name != "room4" {
-		t.Errorf("\nfunction findByName returning incorrect room for valid input"+
+	} else if result != &farm.Network[3] {
+		t.Errorf("\nfunction findByName not returning a pointer to the room within the Network"+
 			"\ngot: %v \nexpected: %v ", result.Name, "room4")
 	}
 
 	// Test invalid input
-	result = findByName("room7")
+	result = solver.findByName("room7")
 	if result != nil {
 		t.Errorf("\nfunction findByName not returning nil for invalid input")
 	}
 }
 
+func TestRunningDFS(t *testing.T) {
+	farm := &sys.Farm{}
+	solver := NewSolver(farm)
+	// Establish test variables. Room names include the " " and "," characters, which separated rooms
+	// and routes in the former string-based search. Routes: S-a b-E, S-c,d-E, S-a b-c,d-E and
+	// S-c,d-a b-E, as well as S-e-f-g-h-E, which is longer than the 4 turns for all ants via S-a b-E
+	farm.TotalAntNbr = 2
+	farm.Network = []sys.Room{{Name: "S", Class: "start"}, {Name: "a b", Class: "intermediate"},
+		{Name: "c,d", Class: "intermediate"}, {Name: "e", Class: "intermediate"},
+		{Name: "f", Class: "intermediate"}, {Name: "g", Class: "intermediate"},
+		{Name: "h", Class: "intermediate"}, {Name: "E", Class: "end"}}
+	linkTestRooms(farm, [][2]int{{0, 1}, {1, 7}, {0, 2}, {2, 7}, {1, 2}, {0, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 7}})
+	farm.Start, farm.End = &farm.Network[0], &farm.Network[7]
+
+	routes, err := solver.runningDFS(context.Background())
+	if err != nil {
+		t.Fatalf("\nfunction runningDFS returning unexpected error for valid input"+
+			"\ngot: %v", err)
+	} else if len(routes) != 4 {
+		t.Fatalf("\nfunction runningDFS not returning the expected number of routes"+
+			"\ngot: %v \nexpected: %v", len(routes), 4)
+	}
+	for i, route := range routes {
+		if i > 0 && len(route) < len(routes[i-1]) {
+			t.Errorf("\nfunction runningDFS not returning routes in ascending order of length")
+		}
+		for _, room := range route {
+			if room != solver.findByName(room.Name) {
+				t.Errorf("\nfunction runningDFS returning route with a room outside the Network"+
+					"\ngot: %v", room.Name)
+			}
+		}
+	}
+	if len(routes[0]) != 3 || len(routes[3]) != 4 {
+		t.Errorf("\nfunction runningDFS not returning the expected routes"+
+			"\ngot: route lengths %v and %v \nexpected: %v and %v", len(routes[0]), len(routes[3]), 3, 4)
+	}
+
+	// Test path cap, and farm without valid routes
+	solver.MaxPaths = 2
+	if routes, err = solver.runningDFS(context.Background()); err != nil || len(routes) != 2 || !solver.Interrupted {
+		t.Errorf("\nfunction runningDFS not capping the number of routes at MaxPaths"+
+			"\ngot: %v, interrupted: %v \nexpected: %v, interrupted: true", len(routes), solver.Interrupted, 2)
+	}
+	farm.Network[0].Links = nil
+	if _, err = solver.runningDFS(context.Background()); err == nil {
+		t.Errorf("\nfunction runningDFS not returning error for farm without valid routes")
+	}
+}
+
+func TestRunningDFSCapSpread(t *testing.T) {
+	// The first branch (through "a") holds three paths, the second (through "b") a single one
+	farm, err := sys.Parse([]string{"1", "##start", "s 0 0", "a 1 0", "b 1 1", "x1 2 0", "x2 2 1", "x3 2 2",
+		"##end", "e 3 0", "s-a", "s-b", "a-x1", "a-x2", "a-x3", "x1-e", "x2-e", "x3-e", "b-x1"})
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error"+
+			"\ngot: %v", err)
+	}
+	solver := NewSolver(farm)
+	solver.MaxPaths = 2
+	routes, err := solver.runningDFS(context.Background())
+	if err != nil || len(routes) != 2 || routes[0][1] == routes[1][1] || !solver.Interrupted {
+		t.Errorf("\nfunction runningDFS not sharing MaxPaths between the first rooms of the paths"+
+			"\ngot: %v, %v, interrupted: %v", routes, err, solver.Interrupted)
+	}
+
+	// Without a cap, every path is found and the search is not interrupted
+	solver.MaxPaths = 0
+	if routes, err = solver.runningDFS(context.Background()); err != nil || len(routes) != 4 || solver.Interrupted {
+		t.Errorf("\nfunction runningDFS not finding every path without MaxPaths"+
+			"\ngot: %v, %v, interrupted: %v", routes, err, solver.Interrupted)
+	}
+}
+
 func TestCheckRouteConflict(t *testing.T) {
+	farm := &sys.Farm{}
 	// Establish test variables / structs
-	sys.Network = []sys.Room{{Name: "1"}, {Name: "2"}, {Name: "3"},
+	farm.Network = []sys.Room{{Name: "1"}, {Name: "2"}, {Name: "3"},
 		{Name: "4"}, {Name: "5"}, {Name: "6"}}
-	route1 := []*sys.Room{&sys.Network[0], &sys.Network[1], &sys.Network[2], &sys.Network[5]}
-	route2 := []*sys.Room{&sys.Network[0], &sys.Network[3], &sys.Network[4], &sys.Network[5]}
-	route3 := []*sys.Room{&sys.Network[0], &sys.Network[2], &sys.Network[5]}
-	route4 := []*sys.Room{&sys.Network[0], &sys.Network[4], &sys.Network[5]}
-	route5 := []*sys.Room{&sys.Network[0], &sys.Network[5]}
+	route1 := []*sys.Room{&farm.Network[0], &farm.Network[1], &farm.Network[2], &farm.Network[5]}
+	route2 := []*sys.Room{&farm.Network[0], &farm.Network[3], &farm.Network[4], &farm.Network[5]}
+	route3 := []*sys.Room{&farm.Network[0], &farm.Network[2], &farm.Network[5]}
+	route4 := []*sys.Room{&farm.Network[0], &farm.Network[4], &farm.Network[5]}
+	route5 := []*sys.Room{&farm.Network[0], &farm.Network[5]}
 	route6 := []*sys.Room{}
 
 	conflict1, errTrue1 := checkRouteConflict(route1, route3)
@@ -63,26 +148,62 @@ func TestCheckRouteConflict(t *testing.T) {
 	}
 }
 
+func TestCheckTunnelConflict(t *testing.T) {
+	farm := &sys.Farm{}
+	// Establish test variables / structs: room 3 is shared by all routes but the last
+	farm.Network = []sys.Room{{Name: "1"}, {Name: "2"}, {Name: "3"},
+		{Name: "4"}, {Name: "5"}, {Name: "6"}}
+	route1 := []*sys.Room{&farm.Network[0], &farm.Network[1], &farm.Network[2], &farm.Network[3], &farm.Network[5]}
+	route2 := []*sys.Room{&farm.Network[0], &farm.Network[4], &farm.Network[2], &farm.Network[5]}
+	route3 := []*sys.Room{&farm.Network[0], &farm.Network[3], &farm.Network[2], &farm.Network[5]}
+	route4 := []*sys.Room{&farm.Network[0], &farm.Network[5]}
+
+	testCases := []struct {
+		route1, route2 []*sys.Room
+		conflict       bool
+	}{
+		{route1, route2, false}, // Shared room, but no shared tunnel
+		{route1, route3, true},  // Tunnel 3-4 crossed in opposite directions
+		{route2, route3, true},  // Tunnel 3-6 to the end room
+		{route1, route4, false},
+	}
+	for i, testCase := range testCases {
+		conflict, err := checkTunnelConflict(testCase.route1, testCase.route2)
+		if conflict != testCase.conflict || err != nil {
+			t.Errorf("\nfunction checkTunnelConflict not returning expected values (%v):"+
+				"\nroute1: %v \nroute2: %v \nconflict: %v \nerror: %v", i, testCase.route1, testCase.route2,
+				conflict, err)
+		}
+	}
+	if _, err := checkTunnelConflict(route1, []*sys.Room{}); err == nil {
+		t.Errorf("\nfunction checkTunnelConflict not detecting invalid input")
+	}
+}
+
 func TestMoveAnt(t *testing.T) {
+	farm := &sys.Farm{}
+	solver := NewSolver(farm)
 	// Establish test variables
-	sys.Network = []sys.Room{{Name: "1", Class: "start", AntID: 0}, {Name: "2", Class: "intermediate", AntID: 4},
-		{Name: "3", Class: "intermediate", AntID: 0}, {Name: "4", Class: "intermediate", AntID: 0},
-		{Name: "5", Class: "intermediate", AntID: 1}, {Name: "6", Class: "end", AntID: 0}}
-	Routes = [][]*sys.Room{{&sys.Network[0], &sys.Network[1], &sys.Network[2], &sys.Network[3], &sys.Network[4], &sys.Network[5]}}
-	errMoveAntFalse1 := moveAnt(Routes[0], 2)
-	errMoveAntFalse2 := moveAnt(Routes[0], 3)
-	errMoveAntValid1 := moveAnt(Routes[0], 1)
-	errMoveAntValid2 := moveAnt(Routes[0], 4) // Test moving to end room
+	farm.Network = []sys.Room{{Name: "1", Class: "start"}, {Name: "2", Class: "intermediate"},
+		{Name: "3", Class: "intermediate"}, {Name: "4", Class: "intermediate"},
+		{Name: "5", Class: "intermediate"}, {Name: "6", Class: "end"}}
+	solver.Routes = [][]*sys.Room{{&farm.Network[0], &farm.Network[1], &farm.Network[2], &farm.Network[3], &farm.Network[4], &farm.Network[5]}}
+	solver.routeAnts = [][]int{{0, 4, 0, 0, 1, 0}}
+	ants := solver.routeAnts[0]
+	errMoveAntFalse1 := solver.moveAnt(0, 2)
+	errMoveAntFalse2 := solver.moveAnt(0, 3)
+	errMoveAntValid1 := solver.moveAnt(0, 1)
+	errMoveAntValid2 := solver.moveAnt(0, 4) // Test moving to end room
 
 	// Perform tests / comparisons of received vs. expected
-	if errMoveAntValid1 != nil || sys.Network[2].AntID != 4 || sys.Network[1].AntID != 0 {
+	if errMoveAntValid1 != nil || ants[2] != 4 || ants[1] != 0 {
 		t.Errorf("\nfunction moveAnt not producing expected results (1)"+
 			"\ngot error: %v got room AntID (to): %v \ngot room AntID (from): %v",
-			errMoveAntValid1, sys.Network[2].AntID, sys.Network[1].AntID)
-	} else if errMoveAntValid2 != nil || sys.Network[4].AntID != 0 || sys.Network[5].AntID != 0 {
+			errMoveAntValid1, ants[2], ants[1])
+	} else if errMoveAntValid2 != nil || ants[4] != 0 || ants[5] != 0 || solver.TotalAntsFinished != 1 {
 		t.Errorf("\nfunction moveAnt not producing expected results (2)"+
 			"\ngot error: %v got room AntID (to): %v \ngot room AntID (from): %v",
-			errMoveAntValid2, sys.Network[5].AntID, sys.Network[4].AntID)
+			errMoveAntValid2, ants[5], ants[4])
 	} else if errMoveAntFalse1 == nil {
 		t.Errorf("\nfunction moveAnt not producing error for invalid input (1)")
 	} else if errMoveAntFalse2 == nil {
@@ -90,27 +211,53 @@ func TestMoveAnt(t *testing.T) {
 	}
 }
 
+func TestMoveAntWeighted(t *testing.T) {
+	farm, err := sys.Parse([]string{"1", "##start", "s 0 0", "a 1 0", "##end", "e 2 0", "#weight 2", "s-a", "a-e"})
+	if err != nil {
+		t.Fatalf("\nerror in parsing farm \ngot: %v", err)
+	}
+	solver := NewSolver(farm)
+	solver.Routes = [][]*sys.Room{{farm.Start, solver.findByName("a"), farm.End}}
+	solver.AntGrouping = []int{1}
+	solver.trackRouteAnts()
+
+	// The ant enters the tunnel to room "a" in the first turn, and only reaches it in the second turn
+	errMoveNew := solver.moveNewAnts()
+	firstTurn := len(solver.CurrentTurn)
+	errMoveExisting := solver.moveExistingAnts()
+	if errMoveNew != nil || errMoveExisting != nil || firstTurn != 0 ||
+		!reflect.DeepEqual(solver.CurrentTurn, []Move{{Ant: 1, From: "s", To: "a"}}) {
+		t.Errorf("\nfunction moveAnt not moving ant through weighted tunnel"+
+			"\ngot moves: %v (turn 1), %v (turn 2) \nerror: %v, %v", firstTurn, solver.CurrentTurn,
+			errMoveNew, errMoveExisting)
+	}
+}
+
 func TestMoveNew(t *testing.T) {
+	farm := &sys.Farm{}
+	solver := NewSolver(farm)
 	// Establish test variables
-	AntID = 6
-	sys.TotalAntNbr = 100
-	sys.Network = []sys.Room{{Name: "1", Class: "start", AntID: 0}, {Name: "2", Class: "intermediate", AntID: 4},
-		{Name: "3", Class: "intermediate", AntID: 0}, {Name: "4", Class: "intermediate", AntID: 1},
-		{Name: "5", Class: "intermediate", AntID: 5}, {Name: "6", Class: "end", AntID: 0}}
+	solver.AntID = 6
+	farm.TotalAntNbr = 100
+	farm.Network = []sys.Room{{Name: "1", Class: "start"}, {Name: "2", Class: "intermediate"},
+		{Name: "3", Class: "intermediate"}, {Name: "4", Class: "intermediate"},
+		{Name: "5", Class: "intermediate"}, {Name: "6", Class: "end"}}
 
 	// Valid input
-	Routes = [][]*sys.Room{
-		{&sys.Network[0], &sys.Network[1], &sys.Network[5]},
-		{&sys.Network[0], &sys.Network[2], &sys.Network[5]}}
-	AntGrouping = []int{0, 2}
-	errMoveNewValid := moveNewAnts()
+	solver.Routes = [][]*sys.Room{
+		{&farm.Network[0], &farm.Network[1], &farm.Network[5]},
+		{&farm.Network[0], &farm.Network[2], &farm.Network[5]}}
+	solver.routeAnts = [][]int{{0, 4, 0}, {0, 0, 0}}
+	solver.AntGrouping = []int{0, 2}
+	errMoveNewValid := solver.moveNewAnts()
 
 	// Invalid input
-	Routes = [][]*sys.Room{
-		{&sys.Network[0], &sys.Network[3], &sys.Network[5]},
-		{&sys.Network[0], &sys.Network[4], &sys.Network[5]}}
-	AntGrouping = []int{2, 1}
-	errMoveNewFalse := moveNewAnts()
+	solver.Routes = [][]*sys.Room{
+		{&farm.Network[0], &farm.Network[3], &farm.Network[5]},
+		{&farm.Network[0], &farm.Network[4], &farm.Network[5]}}
+	solver.routeAnts = [][]int{{0, 1, 0}, {0, 5, 0}}
+	solver.AntGrouping = []int{2, 1}
+	errMoveNewFalse := solver.moveNewAnts()
 
 	// Perform tests / comparisons of received vs. expected
 	if errMoveNewValid != nil {
@@ -122,55 +269,352 @@ func TestMoveNew(t *testing.T) {
 }
 
 func TestMoveExistingAnts(t *testing.T) {
+	farm := &sys.Farm{}
+	solver := NewSolver(farm)
 	// Establish test variables
-	sys.Network = []sys.Room{{Name: "1", Class: "start", AntID: 0}, {Name: "2", Class: "intermediate", AntID: 4},
-		{Name: "3", Class: "intermediate", AntID: 3}, {Name: "4", Class: "intermediate", AntID: 2},
-		{Name: "5", Class: "intermediate", AntID: 1}, {Name: "6", Class: "end", AntID: 0}}
+	farm.Network = []sys.Room{{Name: "1", Class: "start"}, {Name: "2", Class: "intermediate"},
+		{Name: "3", Class: "intermediate"}, {Name: "4", Class: "intermediate"},
+		{Name: "5", Class: "intermediate"}, {Name: "6", Class: "end"}}
 
 	// Valid input
-	Routes = [][]*sys.Room{
-		{&sys.Network[0], &sys.Network[1], &sys.Network[3], &sys.Network[5]},
-		{&sys.Network[0], &sys.Network[2], &sys.Network[4], &sys.Network[5]}}
-	errMoveExistingAnts := moveExistingAnts()
+	solver.Routes = [][]*sys.Room{
+		{&farm.Network[0], &farm.Network[1], &farm.Network[3], &farm.Network[5]},
+		{&farm.Network[0], &farm.Network[2], &farm.Network[4], &farm.Network[5]}}
+	solver.routeAnts = [][]int{{0, 4, 2, 0}, {0, 3, 1, 0}}
+	errMoveExistingAnts := solver.moveExistingAnts()
 
-	gotAntID := []int{sys.Network[0].AntID, sys.Network[0].AntID, sys.Network[0].AntID,
-		sys.Network[0].AntID, sys.Network[0].AntID, sys.Network[0].AntID}
-	correctAntID := []int{0, 0, 4, 3, 2, 0}
+	gotAntID := solver.routeAnts
+	correctAntID := [][]int{{0, 0, 4, 0}, {0, 0, 3, 0}}
 
 	// Perform tests / comparisons of received vs. expected
 	if errMoveExistingAnts != nil {
 		t.Errorf("\nfunction moveExistingAnts producing unexpected error for valid input"+
 			"\ngot: %v", errMoveExistingAnts)
-	} else if reflect.DeepEqual(gotAntID, correctAntID) {
+	} else if !reflect.DeepEqual(gotAntID, correctAntID) || solver.TotalAntsFinished != 2 {
 		t.Errorf("\nfunction moveNewAnts not correctly changing AntID values for rooms"+
 			"\ngot: %v \nexpected: %v", gotAntID, correctAntID)
 	}
 }
 
 func TestExecuteMoves(t *testing.T) {
+	farm := &sys.Farm{}
+	solver := NewSolver(farm)
 	// Establish test variables
-	AntID = 1
-	sys.TotalAntNbr = 99
-	CurrentTurnStr = ""
-	sys.Network = []sys.Room{{Name: "1", Class: "start", AntID: 0}, {Name: "2", Class: "intermediate", AntID: 0},
-		{Name: "3", Class: "intermediate", AntID: 0}, {Name: "4", Class: "intermediate", AntID: 0},
-		{Name: "5", Class: "intermediate", AntID: 0}, {Name: "6", Class: "end", AntID: 0}}
+	solver.AntID = 1
+	farm.TotalAntNbr = 99
+	farm.Network = []sys.Room{{Name: "1", Class: "start"}, {Name: "2", Class: "intermediate"},
+		{Name: "3", Class: "intermediate"}, {Name: "4", Class: "intermediate"},
+		{Name: "5", Class: "intermediate"}, {Name: "6", Class: "end"}}
 
 	// Valid input
-	Routes = [][]*sys.Room{
-		{&sys.Network[0], &sys.Network[1], &sys.Network[2], &sys.Network[5]},
-		{&sys.Network[0], &sys.Network[3], &sys.Network[5]},
-		{&sys.Network[0], &sys.Network[5]}}
-	AntGrouping = []int{32, 33, 34}
+	solver.Routes = [][]*sys.Room{
+		{&farm.Network[0], &farm.Network[1], &farm.Network[2], &farm.Network[5]},
+		{&farm.Network[0], &farm.Network[3], &farm.Network[5]},
+		{&farm.Network[0], &farm.Network[5]}}
+	solver.AntGrouping = []int{32, 33, 34}
+	solver.trackRouteAnts()
 
-	errExecuteMoves := executeMoves()
+	errExecuteMoves := solver.executeMoves()
 
 	// Perform tests / comparisons of received vs. expected
 	if errExecuteMoves != nil {
 		t.Errorf("\nfunction executeMoves returning unexpected error for valid input"+
 			"\ngot: %v", errExecuteMoves)
-	} else if AntID != sys.TotalAntNbr {
+	} else if solver.AntID != farm.TotalAntNbr {
 		t.Errorf("\nfunction executeMoves not altering counters corrently"+
-			"\ngot TotalAntNbr: %v \ngot AntID: %v", sys.TotalAntNbr, AntID)
+			"\ngot TotalAntNbr: %v \ngot AntID: %v", farm.TotalAntNbr, solver.AntID)
+	}
+}
+
+/*
+failingWriter is an io.Writer which always fails, for testing the handling of write errors.
+*/
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestExecuteMovesOutput(t *testing.T) {
+	// Establish test variables: two routes (one direct), for 3 ants
+	newTestSolver := func(output io.Writer) *Solver {
+		farm := &sys.Farm{TotalAntNbr: 3}
+		farm.Network = []sys.Room{{Name: "s", Class: "start"}, {Name: "a", Class: "intermediate"},
+			{Name: "e", Class: "end"}}
+		solver := NewSolver(farm)
+		solver.Output = output
+		solver.Routes = [][]*sys.Room{{&farm.Network[0], &farm.Network[2]},
+			{&farm.Network[0], &farm.Network[1], &farm.Network[2]}}
+		solver.AntGrouping = []int{2, 1}
+		solver.trackRouteAnts()
+		return solver
+	}
+
+	// Test moves written turn by turn
+	var output bytes.Buffer
+	solver := newTestSolver(&output)
+	if err := solver.executeMoves(); err != nil {
+		t.Fatalf("\nfunction executeMoves returning unexpected error for valid input"+
+			"\ngot: %v", err)
+	}
+	expected := "\nL1-e L2-a\nL2-e L3-e\n\n"
+	if output.String() != expected || solver.TurnCount != 2 {
+		t.Errorf("\nfunction executeMoves not writing the expected moves"+
+			"\ngot: %q (%v turns) \nexpected: %q (%v turns)", output.String(), solver.TurnCount, expected, 2)
+	}
+
+	// Test summary only
+	output.Reset()
+	solver = newTestSolver(&output)
+	solver.SummaryOnly = true
+	if err := solver.executeMoves(); err != nil {
+		t.Fatalf("\nfunction executeMoves returning unexpected error for valid input"+
+			"\ngot: %v", err)
+	}
+	if expected = "\nturns: 2\n\n"; output.String() != expected {
+		t.Errorf("\nfunction executeMoves not writing only the number of turns with SummaryOnly"+
+			"\ngot: %q \nexpected: %q", output.String(), expected)
+	}
+
+	// Test write errors
+	solver = newTestSolver(failingWriter{})
+	if err := solver.executeMoves(); err == nil {
+		t.Errorf("\nfunction executeMoves not returning error for failing Output")
+	}
+}
+
+func TestSolverConcurrent(t *testing.T) {
+	// Establish test farms (each solved by several Solvers at once)
+	farmContents := [][]string{
+		{"4", "##start", "0 0 3", "2 2 5", "3 4 0", "##end", "1 8 3", "0-2", "2-3", "3-1"},
+		{"20", "##start", "s 0 0", "a 1 0", "b 1 1", "c 2 0", "##end", "e 3 0",
+			"s-a", "s-b", "a-c", "b-c", "c-e", "b-e"}}
+
+	// Solve farms sequentially for reference outputs
+	farms := make([]*sys.Farm, len(farmContents))
+	expected := make([]string, len(farmContents))
+	for i, contents := range farmContents {
+		farm, err := sys.Parse(contents)
+		if err != nil {
+			t.Fatalf("\nerror in parsing test farm %v \ngot: %v", i, err)
+		}
+		farms[i] = farm
+		var output bytes.Buffer
+		solver := NewSolver(farm)
+		solver.Algorithm = AlgorithmFlow
+		solver.Output = &output
+		if err = solver.Run(context.Background()); err != nil {
+			t.Fatalf("\nSolver returning unexpected error for test farm %v \ngot: %v", i, err)
+		}
+		expected[i] = output.String()
+	}
+
+	// Solve farms concurrently, several times each
+	var wg sync.WaitGroup
+	results := make([]string, 4*len(farmContents))
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var output bytes.Buffer
+			solver := NewSolver(farms[i%len(farms)])
+			solver.Algorithm = AlgorithmFlow
+			solver.Output = &output
+			errs[i] = solver.Run(context.Background())
+			results[i] = output.String()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if errs[i] != nil {
+			t.Errorf("\nSolver returning unexpected error when run concurrently (%v) \ngot: %v", i, errs[i])
+		} else if result != expected[i%len(farmContents)] {
+			t.Errorf("\nSolver producing different output when run concurrently (%v)"+
+				"\ngot: %v \nexpected: %v", i, result, expected[i%len(farmContents)])
+		}
+	}
+}
+
+func TestSolverWorkers(t *testing.T) {
+	// Farms with many equally rated route combinations, so that ties must be broken
+	var farmContents [][]string
+	for _, topology := range []string{generate.TopologyRandom, generate.TopologyGrid, generate.TopologyBottleneck} {
+		for seed := int64(1); seed <= 5; seed++ {
+			fileContents, err := generate.Generate(generate.Options{Rooms: 9, Density: 0.5, Ants: 5 * int(seed),
+				CoordRange: 20, Connectivity: generate.ConnectGuaranteed, Topology: topology, Seed: seed})
+			if err != nil {
+				t.Fatalf("\nerror in generating farm \ngot: %v", err)
+			}
+			farmContents = append(farmContents, fileContents)
+		}
+	}
+
+	for i, contents := range farmContents {
+		var expected string
+		for _, workers := range []int{1, 2, 3, 8, 8} {
+			farm, err := sys.Parse(contents)
+			if err != nil {
+				t.Fatalf("\nerror in parsing test farm %v \ngot: %v", i, err)
+			}
+			var output bytes.Buffer
+			solver := NewSolver(farm)
+			solver.Workers = workers
+			solver.EchoInput = false
+			solver.Output = &output
+			if err = solver.Run(context.Background()); err != nil {
+				t.Fatalf("\nSolver returning unexpected error for test farm %v \ngot: %v", i, err)
+			}
+			if workers == 1 {
+				expected = output.String()
+			} else if output.String() != expected {
+				t.Errorf("\nSolver producing different output for test farm %v with %v workers"+
+					"\ngot: %v \nexpected: %v", i, workers, output.String(), expected)
+			}
+		}
+	}
+}
+
+func TestFindBestRouteCombo(t *testing.T) {
+	farm := &sys.Farm{}
+	// Establish test variables: 6 routes without conflicts, and a rating function which rates every
+	// combination the same, so that the chosen combination depends only on the tie-break
+	farm.Network = make([]sys.Room, 8)
+	allRoutes := make([][]*sys.Room, 6)
+	routeConflictMap := make(map[int][]int, len(allRoutes))
+	for i := range allRoutes {
+		allRoutes[i] = []*sys.Room{&farm.Network[0], &farm.Network[i+1], &farm.Network[7]}
+		routeConflictMap[i] = []int{}
+	}
+	sameRating := func([][]*sys.Room, []int) ([]int, error) { return []int{5, 10}, nil }
+
+	for _, workers := range []int{1, 2, 3, 4, 8, 8, 8} {
+		routes, err := findBestRouteCombo(context.Background(), allRoutes, routeConflictMap, nil, sameRating, workers)
+		if err != nil {
+			t.Fatalf("\nfunction findBestRouteCombo returning unexpected error for valid input"+
+				"\ngot: %v", err)
+		} else if len(routes) != 1 || routes[0][1] != &farm.Network[1] {
+			t.Errorf("\nfunction findBestRouteCombo not breaking ties with the lowest combination (%v workers)"+
+				"\ngot: %v \nexpected: %v", workers, routes, allRoutes[:1])
+		}
+	}
+}
+
+/*
+greedyAntGrouping is the reference for DistributeAnts: each ant in turn is sent down the route where it
+would finish first (least ants assigned plus route length, the first such route in case of a tie).
+*/
+func greedyAntGrouping(routeLengths []int, ants int) []int {
+	grouping := make([]int, len(routeLengths))
+	for ant := 0; ant < ants; ant++ {
+		best := 0
+		for i, length := range routeLengths {
+			if grouping[i]+length < grouping[best]+routeLengths[best] {
+				best = i
+			}
+		}
+		grouping[best]++
+	}
+	return grouping
+}
+
+func TestDistributeAnts(t *testing.T) {
+	// Test edge cases
+	if grouping := DistributeAnts([]int{}, 10); len(grouping) != 0 {
+		t.Errorf("\nfunction DistributeAnts not returning an empty grouping for no routes \ngot: %v", grouping)
+	}
+	if grouping := DistributeAnts([]int{3, 5}, 0); !reflect.DeepEqual(grouping, []int{0, 0}) {
+		t.Errorf("\nfunction DistributeAnts not returning an empty grouping for no ants \ngot: %v", grouping)
+	}
+	if grouping := DistributeAnts([]int{4, 2, 4, 9}, 7); !reflect.DeepEqual(grouping, []int{2, 4, 1, 0}) {
+		t.Errorf("\nfunction DistributeAnts not returning the expected grouping"+
+			"\ngot: %v \nexpected: %v", grouping, []int{2, 4, 1, 0})
+	}
+
+	// Test random routes (sorted or not) against the greedy assignment, one ant at a time
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		routeLengths := make([]int, 1+random.Intn(10))
+		for j := range routeLengths {
+			routeLengths[j] = 2 + random.Intn(15)
+		}
+		if i%2 == 0 {
+			sort.Ints(routeLengths)
+		}
+		ants := random.Intn(200)
+		grouping := DistributeAnts(routeLengths, ants)
+		expected := greedyAntGrouping(routeLengths, ants)
+		if !reflect.DeepEqual(grouping, expected) {
+			t.Errorf("\nfunction DistributeAnts not matching the greedy assignment for routes %v and %v ants"+
+				"\ngot: %v \nexpected: %v", routeLengths, ants, grouping, expected)
+		}
+	}
+}
+
+func TestRepeatRoutes(t *testing.T) {
+	// Room "a" holds two ants, room "b" one
+	farm, err := sys.Parse([]string{"3", "##start", "s 0 0", "#capacity 2", "a 1 0", "b 1 1", "##end", "e 2 0",
+		"s-a", "a-e", "s-b", "b-e"})
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error"+
+			"\ngot: %v", err)
+	}
+	solver := NewSolver(farm)
+	routes, err := solver.runningDFS(context.Background())
+	if err != nil || len(routes) != 2 {
+		t.Fatalf("\nfunction runningDFS not returning expected routes"+
+			"\ngot: %v, %v", routes, err)
+	}
+	repeated, copies := repeatRoutes(routes)
+	if len(repeated) != 3 || !reflect.DeepEqual(copies, []int{0, 1, 0}) || repeated[1][1].Name != "a" {
+		t.Errorf("\nfunction repeatRoutes not repeating route through room holding several ants"+
+			"\ngot: %v, %v", repeated, copies)
+	}
+
+	// A copy is only accepted alongside the previous copy (combinations being built one route at a time), even
+	// if the routes are copied
+	fits := solver.capacityCheck(append([][]*sys.Room{}, repeated...), copies)
+	combinations := [][]int{{0}, {0, 1}, {1}, {2}, {0, 2}, {0, 1, 2}}
+	correctFits := []bool{true, true, false, true, true, true}
+	for i, combination := range combinations {
+		if fits(combination) != correctFits[i] {
+			t.Errorf("\nfunction capacityCheck not returning expected result for combination %v"+
+				"\ngot: %v \nexpected: %v", combination, !correctFits[i], correctFits[i])
+		}
+	}
+}
+
+func TestPrepareStep(t *testing.T) {
+	farm, err := sys.Parse([]string{"3", "##start", "s 0 0", "a 1 0", "b 1 1", "c 2 1", "##end", "e 3 0", "s-a",
+		"a-e", "s-b", "b-c", "c-e"})
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error"+
+			"\ngot: %v", err)
+	}
+	solver := NewSolver(farm)
+	solver.RecordTurns = true
+
+	// Preparing twice must start the ants over, as after a Run
+	for run := 0; run < 2; run++ {
+		if err = solver.Prepare(context.Background()); err != nil {
+			t.Fatalf("\nfunction Prepare returning unexpected error"+
+				"\ngot: %v", err)
+		}
+		steps := 0
+		for {
+			moved, errStep := solver.Step()
+			if errStep != nil {
+				t.Fatalf("\nfunction Step returning unexpected error"+
+					"\ngot: %v", errStep)
+			} else if !moved {
+				break
+			}
+			steps++
+		}
+		if steps != 3 || solver.TurnCount != 3 || len(solver.Turns) != 3 || solver.TotalAntsFinished != 3 {
+			t.Errorf("\nfunction Step not moving every ant in expected number of turns (run %v)"+
+				"\ngot: %v steps, %v turns, %v finished", run, steps, solver.TurnCount, solver.TotalAntsFinished)
+		}
 	}
 }
@@ -0,0 +1,433 @@
+package routing
+
+import (
+	"bytes"
+	"context"
+	"lem-in/generate"
+	"lem-in/sys"
+	"lem-in/verify"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+solveAndCheck parses the input file contents, solves the farm with the given algorithm and rule set (and context)
+and replays the printed moves with verify.CheckRules, failing the test for any violation of the rules: moves
+which do not follow a tunnel, more than one ant in an intermediate room (or through a tunnel, for
+sys.RulesTunnels), ants moving more than once in a turn, and ants which do not end in the end room. The
+Solver is returned, with the moves of every turn in its Turns.
+*/
+func solveAndCheck(t *testing.T, ctx context.Context, name string, fileContents []string, algorithm,
+	rules string) *Solver {
+	farm, err := sys.Parse(fileContents)
+	if err != nil {
+		t.Fatalf("\n%v: error in parsing farm \ngot: %v", name, err)
+	}
+	var output bytes.Buffer
+	solver := NewSolver(farm)
+	solver.Algorithm = algorithm
+	solver.Rules = rules
+	solver.Output = &output
+	solver.RecordTurns = true
+	if err = solver.Run(ctx); err != nil {
+		t.Fatalf("\n%v: Solver (%v) returning unexpected error \ngot: %v", name, algorithm, err)
+	}
+
+	report, err := verify.CheckRules(farm, strings.Split(output.String(), "\n"), rules)
+	if err != nil {
+		t.Fatalf("\n%v: function verify.CheckRules returning unexpected error \ngot: %v", name, err)
+	}
+	for _, violation := range report.Violations {
+		t.Errorf("\n%v: Solver (%v) breaking the rules \ngot: %v", name, algorithm, violation)
+	}
+	if report.Turns != len(solver.Turns) {
+		t.Errorf("\n%v: Solver (%v) printing and recording a different number of turns"+
+			"\nprinted: %v \nrecorded: %v", name, algorithm, report.Turns, len(solver.Turns))
+	}
+	return solver
+}
+
+/*
+bruteForceTurns returns the optimal number of turns for a (small) farm, independently of the Solver:
+every set of vertex-disjoint routes between the start and end rooms is tried, with the number of turns
+of a set of routes taking e_1, e_2 ... turns (their number of tunnels, unless tunnels are weighted) being
+the smallest T for which the routes can carry all ants (route i can send an ant in each of the first
+T - e_i + 1 turns).
+*/
+func bruteForceTurns(farm *sys.Farm) int {
+	var routes [][]*sys.Room
+	var search func(route []*sys.Room, visited map[*sys.Room]bool)
+	search = func(route []*sys.Room, visited map[*sys.Room]bool) {
+		room := route[len(route)-1]
+		if room == farm.End {
+			routes = append(routes, append([]*sys.Room{}, route...))
+			return
+		}
+		for _, link := range room.Links {
+			if !visited[link] {
+				visited[link] = true
+				search(append(route, link), visited)
+				visited[link] = false
+			}
+		}
+	}
+	search([]*sys.Room{farm.Start}, map[*sys.Room]bool{farm.Start: true})
+
+	best := -1
+	var combine func(next int, used map[*sys.Room]bool, tunnels []int)
+	combine = func(next int, used map[*sys.Room]bool, tunnels []int) {
+		if len(tunnels) > 0 {
+			turns := 1
+			for capacity := 0; capacity < farm.TotalAntNbr; turns++ {
+				capacity = 0
+				for _, e := range tunnels {
+					if turns-e+1 > 0 {
+						capacity += turns - e + 1
+					}
+				}
+			}
+			if best < 0 || turns-1 < best {
+				best = turns - 1
+			}
+		}
+		for i := next; i < len(routes); i++ {
+			free := true
+			for _, room := range routes[i][1 : len(routes[i])-1] {
+				free = free && !used[room]
+			}
+			if !free {
+				continue
+			}
+			for _, room := range routes[i][1 : len(routes[i])-1] {
+				used[room] = true
+			}
+			combine(i+1, used, append(tunnels, routeTurns(routes[i])))
+			for _, room := range routes[i][1 : len(routes[i])-1] {
+				used[room] = false
+			}
+		}
+	}
+	combine(0, map[*sys.Room]bool{}, nil)
+	return best
+}
+
+func TestSolutionExamples(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "sys", "examples", "example*.txt"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("\nerror in finding example files \ngot: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("\nerror in reading example file %v \ngot: %v", file, err)
+		}
+		fileContents := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+		name := filepath.Base(file)
+		dfsTurns := len(solveAndCheck(t, context.Background(), name, fileContents, AlgorithmDFS, sys.RulesRooms).Turns)
+		flowTurns := len(solveAndCheck(t, context.Background(), name, fileContents, AlgorithmFlow, sys.RulesRooms).Turns)
+		if flowTurns != dfsTurns {
+			t.Errorf("\n%v: Solver returning a different number of turns for each algorithm"+
+				"\ndfs: %v \nflow: %v", name, dfsTurns, flowTurns)
+		}
+	}
+}
+
+/*
+generatedFarms generates a small farm for each of the topologies and each seed from 1 to seeds, with between
+rooms and twice as many rooms (and at least ants ants) depending on the seed, and calls test with the name
+and the file contents of each farm. The start and end rooms of the farms are always connected.
+*/
+func generatedFarms(t *testing.T, topologies []string, seeds int64, rooms, ants int,
+	test func(name string, fileContents []string)) {
+	for _, topology := range topologies {
+		for seed := int64(1); seed <= seeds; seed++ {
+			options := generate.Options{Rooms: rooms + int(seed%int64(rooms+1)), Density: 0.5,
+				Ants: ants + int(seed*7%13), CoordRange: 20, Connectivity: generate.ConnectGuaranteed,
+				Topology: topology, Seed: seed}
+			fileContents, err := generate.Generate(options)
+			if err != nil {
+				t.Fatalf("\nerror in generating farm for options %+v \ngot: %v", options, err)
+			}
+			test(topology+" seed "+strconv.FormatInt(seed, 10), fileContents)
+		}
+	}
+}
+
+/*
+allTopologies lists every topology of the generate package.
+*/
+var allTopologies = []string{generate.TopologyRandom, generate.TopologyGrid, generate.TopologyTree,
+	generate.TopologyBottleneck, generate.TopologyCorridor}
+
+func TestSolutionGenerated(t *testing.T) {
+	// Small farms (4 to 8 rooms) of every topology, for which the optimum can be found by brute force
+	generatedFarms(t, allTopologies, 20, 4, 1, func(name string, fileContents []string) {
+		farm, _ := sys.Parse(fileContents)
+		optimum := bruteForceTurns(farm)
+		for _, algorithm := range []string{AlgorithmDFS, AlgorithmFlow} {
+			turns := len(solveAndCheck(t, context.Background(), name, fileContents, algorithm, sys.RulesRooms).Turns)
+			if turns != optimum {
+				t.Errorf("\n%v: Solver (%v) not returning the optimal number of turns"+
+					"\ngot: %v \nexpected: %v \nfarm: %v", name, algorithm, turns, optimum,
+					strings.Join(fileContents, "\n"))
+			}
+		}
+	})
+}
+
+func TestSolutionTimeout(t *testing.T) {
+	// A grid farm with far too many route combinations to be rated in the time given
+	fileContents, err := generate.Generate(generate.Options{Rooms: 30, Ants: 30, CoordRange: 20,
+		Connectivity: generate.ConnectGuaranteed, Topology: generate.TopologyGrid, Seed: 1})
+	if err != nil {
+		t.Fatalf("\nerror in generating farm \ngot: %v", err)
+	}
+	for _, algorithm := range []string{AlgorithmDFS, AlgorithmFlow} {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		begin := time.Now()
+		solver := solveAndCheck(t, ctx, "grid (timeout)", fileContents, algorithm, sys.RulesRooms)
+		cancel()
+		if elapsed := time.Since(begin); elapsed > 5*time.Second {
+			t.Errorf("\nSolver (%v) not stopping the route search once the context expired"+
+				"\ngot: %v", algorithm, elapsed)
+		}
+		if algorithm == AlgorithmDFS && !solver.Interrupted {
+			t.Errorf("\nSolver (%v) not flagging the solution as interrupted", algorithm)
+		}
+	}
+
+	// A context which is already cancelled still gives a (valid) solution, flagged as interrupted
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, algorithm := range []string{AlgorithmDFS, AlgorithmFlow} {
+		if solver := solveAndCheck(t, ctx, "grid (cancelled)", fileContents, algorithm, sys.RulesRooms); !solver.Interrupted {
+			t.Errorf("\nSolver (%v) not flagging the solution as interrupted", algorithm)
+		}
+	}
+}
+
+func TestSolutionTunnels(t *testing.T) {
+	// A bowtie farm, where every route crosses the hub room "h": under the room rules a single route can
+	// be used, while under the tunnel rules two routes share the hub
+	bowtie := []string{"10", "##start", "s 0 2", "a 1 1", "b 1 3", "h 2 2", "c 3 1", "d 3 3", "##end", "e 4 2",
+		"s-a", "s-b", "a-h", "b-h", "h-c", "h-d", "c-e", "d-e"}
+	for _, algorithm := range []string{AlgorithmDFS, AlgorithmFlow} {
+		roomTurns := len(solveAndCheck(t, context.Background(), "bowtie", bowtie, algorithm, sys.RulesRooms).Turns)
+		tunnelTurns := len(solveAndCheck(t, context.Background(), "bowtie", bowtie, algorithm, sys.RulesTunnels).Turns)
+		if roomTurns != 13 || tunnelTurns != 8 {
+			t.Errorf("\nbowtie: Solver (%v) not returning the optimal number of turns"+
+				"\ngot: %v (rooms), %v (tunnels) \nexpected: %v (rooms), %v (tunnels)", algorithm,
+				roomTurns, tunnelTurns, 13, 8)
+		}
+	}
+
+	// Sharing rooms can only ever help, and both algorithms must agree
+	generatedFarms(t, allTopologies, 10, 4, 1, func(name string, fileContents []string) {
+		roomTurns := len(solveAndCheck(t, context.Background(), name, fileContents, AlgorithmDFS,
+			sys.RulesRooms).Turns)
+		dfsTurns := len(solveAndCheck(t, context.Background(), name, fileContents, AlgorithmDFS,
+			sys.RulesTunnels).Turns)
+		flowTurns := len(solveAndCheck(t, context.Background(), name, fileContents, AlgorithmFlow,
+			sys.RulesTunnels).Turns)
+		if dfsTurns != flowTurns || dfsTurns > roomTurns {
+			t.Errorf("\n%v: Solver not returning the expected number of turns under the tunnel rules"+
+				"\ngot: %v (dfs), %v (flow) \nexpected at most: %v \nfarm: %v", name, dfsTurns, flowTurns,
+				roomTurns, strings.Join(fileContents, "\n"))
+		}
+	})
+}
+
+/*
+withCapacities returns a copy of the input file contents with a "#capacity N" line (N from 1 to 3, in turn)
+before every intermediate room line.
+*/
+func withCapacities(fileContents []string) []string {
+	var output []string
+	label := false
+	for i, line := range fileContents {
+		if sys.RegexRoom.MatchString(line) && !label {
+			output = append(output, "#capacity "+strconv.Itoa(1+i%3))
+		}
+		label = sys.RegexStart.MatchString(line) || sys.RegexEnd.MatchString(line)
+		output = append(output, line)
+	}
+	return output
+}
+
+func TestSolutionCapacity(t *testing.T) {
+	// Rooms "a" and "b" hold three ants, so that route s-a-b-e can carry several ants per turn
+	chambers := []string{"10", "##start", "s 0 1", "#capacity 3", "a 1 1", "#capacity 3", "b 2 1", "c 1 2",
+		"##end", "e 3 1", "s-a", "a-b", "b-e", "s-c", "c-e"}
+	for _, algorithm := range []string{AlgorithmDFS, AlgorithmFlow} {
+		turns := len(solveAndCheck(t, context.Background(), "chambers", chambers, algorithm, sys.RulesRooms).Turns)
+		if turns != 5 {
+			t.Errorf("\nchambers: Solver (%v) not returning the optimal number of turns"+
+				"\ngot: %v \nexpected: %v", algorithm, turns, 5)
+		}
+	}
+
+	// Larger rooms can only ever help, and both algorithms must agree
+	generatedFarms(t, allTopologies, 10, 4, 1, func(name string, fileContents []string) {
+		roomTurns := len(solveAndCheck(t, context.Background(), name, fileContents, AlgorithmFlow,
+			sys.RulesRooms).Turns)
+		fileContents = withCapacities(fileContents)
+		dfsTurns := len(solveAndCheck(t, context.Background(), name, fileContents, AlgorithmDFS,
+			sys.RulesRooms).Turns)
+		flowTurns := len(solveAndCheck(t, context.Background(), name, fileContents, AlgorithmFlow,
+			sys.RulesRooms).Turns)
+		if dfsTurns != flowTurns || dfsTurns > roomTurns {
+			t.Errorf("\n%v: Solver not returning the expected number of turns with room capacities"+
+				"\ngot: %v (dfs), %v (flow) \nexpected at most: %v \nfarm: %v", name, dfsTurns, flowTurns,
+				roomTurns, strings.Join(fileContents, "\n"))
+		}
+	})
+}
+
+/*
+withWeights returns a copy of the input file contents with a "#weight N" line (N from 1 to 4, in turn) before
+every link line.
+*/
+func withWeights(fileContents []string) []string {
+	var output []string
+	for i, line := range fileContents {
+		if sys.RegexLink.MatchString(line) {
+			output = append(output, "#weight "+strconv.Itoa(1+i%4))
+		}
+		output = append(output, line)
+	}
+	return output
+}
+
+func TestSolutionWeights(t *testing.T) {
+	// The short route s-a-e is slow, so that the first ants take the long route s-b-c-e
+	detour := []string{"4", "##start", "s 0 1", "a 1 1", "b 1 2", "c 2 2", "##end", "e 3 1", "#weight 5", "s-a",
+		"a-e", "s-b", "b-c", "c-e"}
+	for _, algorithm := range []string{AlgorithmDFS, AlgorithmFlow} {
+		for _, rules := range []string{sys.RulesRooms, sys.RulesTunnels} {
+			turns := len(solveAndCheck(t, context.Background(), "detour", detour, algorithm, rules).Turns)
+			if turns != 6 {
+				t.Errorf("\ndetour: Solver (%v, %v) not returning the optimal number of turns"+
+					"\ngot: %v \nexpected: %v", algorithm, rules, turns, 6)
+			}
+		}
+	}
+
+	// Small weighted farms, for which the optimum can be found by brute force
+	generatedFarms(t, allTopologies, 20, 4, 1, func(name string, fileContents []string) {
+		fileContents = withWeights(fileContents)
+		farm, _ := sys.Parse(fileContents)
+		optimum := bruteForceTurns(farm)
+		for _, algorithm := range []string{AlgorithmDFS, AlgorithmFlow} {
+			turns := len(solveAndCheck(t, context.Background(), name, fileContents, algorithm, sys.RulesRooms).Turns)
+			if turns != optimum {
+				t.Errorf("\n%v: Solver (%v) not returning the optimal number of turns with weighted tunnels"+
+					"\ngot: %v \nexpected: %v \nfarm: %v", name, algorithm, turns, optimum,
+					strings.Join(fileContents, "\n"))
+			}
+		}
+	})
+}
+
+/*
+withTerminals returns a copy of the input file contents (as generated, with rooms "start" and "end") with a
+"##multi" line, and a second start room "t0" and second end room "t1", linked to the same rooms as "start"
+and "end" respectively. If split is true, the ants are split between the two start rooms ("##start N"), the
+second one taking half of them (rounded down) (and linked to "t1" as well, so that it always has a route of
+its own).
+*/
+func withTerminals(fileContents []string, split bool) []string {
+	ants, _ := strconv.Atoi(strings.TrimSpace(fileContents[0]))
+	output := []string{fileContents[0], "##multi"}
+	var links []string
+	for _, line := range fileContents[1:] {
+		if split && sys.RegexStart.MatchString(line) {
+			line = "##start " + strconv.Itoa(ants-ants/2)
+		}
+		if sys.RegexLink.MatchString(line) {
+			rooms := strings.Split(line, "-")
+			for i, room := range rooms {
+				if room == "start" {
+					links = append(links, "t0-"+rooms[1-i])
+				} else if room == "end" {
+					links = append(links, "t1-"+rooms[1-i])
+				}
+			}
+		}
+		output = append(output, line)
+	}
+	if split {
+		output = append(output, "##start "+strconv.Itoa(ants/2))
+	} else {
+		output = append(output, "##start")
+	}
+	output = append(output, "t0 -1 -1", "##end", "t1 -2 -2")
+	if split {
+		links = append(links, "t0-t1")
+	}
+	return append(output, links...)
+}
+
+func TestSolutionMultiple(t *testing.T) {
+	// Two start rooms with 4 and 2 ants, sharing the end rooms e1 and e2
+	terminals := []string{"6", "##multi", "##start 4", "s1 0 0", "##start 2", "s2 0 4", "a 2 0", "b 2 4",
+		"c 2 2", "##end", "e1 4 0", "##end", "e2 4 4", "s1-a", "a-e1", "s2-b", "b-e2", "s1-c", "c-e2"}
+	shared := append([]string{}, terminals...)
+	shared[2], shared[4] = "##start", "##start"
+	for _, algorithm := range []string{AlgorithmDFS, AlgorithmFlow} {
+		for _, rules := range []string{sys.RulesRooms, sys.RulesTunnels} {
+			solver := solveAndCheck(t, context.Background(), "terminals", terminals, algorithm, rules)
+			if len(solver.Turns) != 3 {
+				t.Errorf("\nterminals: Solver (%v, %v) not returning the optimal number of turns"+
+					"\ngot: %v \nexpected: %v", algorithm, rules, len(solver.Turns), 3)
+			}
+			for _, move := range solver.Turns[0] {
+				if (move.From == "s2") != (move.Ant > 4) {
+					t.Errorf("\nterminals: Solver (%v, %v) not numbering the ants by start room"+
+						"\ngot: %+v", algorithm, rules, move)
+				}
+			}
+			solver = solveAndCheck(t, context.Background(), "shared", shared, algorithm, rules)
+			if len(solver.Turns) != 3 {
+				t.Errorf("\nshared: Solver (%v, %v) not returning the optimal number of turns"+
+					"\ngot: %v \nexpected: %v", algorithm, rules, len(solver.Turns), 3)
+			}
+		}
+	}
+
+	// All ants of a start room without a way out
+	stranded := []string{"3", "##multi", "##start 2", "s1 0 0", "##start 1", "s2 0 4", "##end", "e 4 0", "s1-e"}
+	for _, algorithm := range []string{AlgorithmDFS, AlgorithmFlow} {
+		farm, err := sys.Parse(stranded)
+		if err != nil {
+			t.Fatalf("\nstranded: error in parsing farm \ngot: %v", err)
+		}
+		solver := NewSolver(farm)
+		solver.Algorithm = algorithm
+		solver.Output = &bytes.Buffer{}
+		if err = solver.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "s2") {
+			t.Errorf("\nstranded: Solver (%v) not returning expected error for start room without routes"+
+				"\ngot: %v", algorithm, err)
+		}
+	}
+
+	// Generated farms with a second start and end room, with the ants shared or split between the start rooms
+	generatedFarms(t, []string{generate.TopologyRandom}, 20, 5, 2, func(name string, fileContents []string) {
+		for _, split := range []bool{false, true} {
+			multiple := withTerminals(fileContents, split)
+			name := name + " split " + strconv.FormatBool(split)
+			// Under sys.RulesTunnels, routes from different start rooms seldom conflict, so that the route
+			// combinations rated by the depth-first search are too many for a quick test
+			solveAndCheck(t, context.Background(), name, multiple, AlgorithmFlow, sys.RulesTunnels)
+			dfs := len(solveAndCheck(t, context.Background(), name, multiple, AlgorithmDFS, sys.RulesRooms).Turns)
+			flow := len(solveAndCheck(t, context.Background(), name, multiple, AlgorithmFlow, sys.RulesRooms).Turns)
+			if dfs > flow {
+				t.Errorf("\n%v: Solver (dfs) taking more turns than the max-flow algorithm"+
+					"\ngot: %v \nexpected at most: %v \nfarm: %v", name, dfs, flow, strings.Join(multiple, "\n"))
+			}
+		}
+	})
+}
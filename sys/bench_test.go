@@ -0,0 +1,26 @@
+package sys
+
+import (
+	"lem-in/generate"
+	"strconv"
+	"testing"
+)
+
+func BenchmarkParse(b *testing.B) {
+	for _, rooms := range []int{10, 100, 1000, 2000} {
+		options := generate.Options{Rooms: rooms, Density: 0.5, Ants: 100, CoordRange: 10 * rooms,
+			Connectivity: generate.ConnectGuaranteed, Topology: generate.TopologyRandom, Seed: 1}
+		fileContents, err := generate.Generate(options)
+		if err != nil {
+			b.Fatalf("\nerror in generating farm \ngot: %v", err)
+		}
+		b.Run("rooms="+strconv.Itoa(rooms), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse(fileContents); err != nil {
+					b.Fatalf("\nerror in parsing generated farm \ngot: %v", err)
+				}
+			}
+		})
+	}
+}
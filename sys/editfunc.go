@@ -0,0 +1,104 @@
+package sys
+
+import (
+	"strconv"
+)
+
+/*
+AddLink is the editing counterpart of readLinks, for changing a Farm once it has been parsed (e.g. from an
+interactive session): the link line (e.g. "room1-room2") is parsed and written to the Network and
+NetworkMap of the Farm, taking the given number of turns to cross (see Room.Weight, 1 or less being a
+single turn). A non-nil error is returned if the link is poorly formatted, links a room to itself or to an
+unknown room, or already exists.
+*/
+func (farm *Farm) AddLink(linkLine string, weight int) error {
+	linkSlice, errParseLinks := parseLinks(linkLine)
+	if errParseLinks != nil {
+		return errParseLinks
+	}
+	return farm.writeLinks(linkSlice, weight)
+}
+
+/*
+RemoveRoom removes the named room from the Network of the Farm, along with its links. As the rooms of the
+Network are moved to close the gap, every pointer to a room (links, weights, start and end rooms and the
+NetworkMap) is written anew. The ants of a start room given its own ants (see HasStartAnts) are removed along
+with it. A non-nil error is returned if the room is not found, or if it is the only start room or the only
+end room of the Farm.
+*/
+func (farm *Farm) RemoveRoom(roomName string) error {
+	index, errFindRoom := farm.findRoomIndex(roomName)
+	if errFindRoom != nil {
+		return newParseError(ErrUnknownRoom, "room not found: "+roomName)
+	}
+	removed := &farm.Network[index]
+	if removed.Class == "start" && len(farm.Starts) <= 1 {
+		return newParseError(ErrNoStart, "the only start room \" "+roomName+" \" can not be removed")
+	} else if removed.Class == "end" && len(farm.Ends) <= 1 {
+		return newParseError(ErrNoEnd, "the only end room \" "+roomName+" \" can not be removed")
+	}
+
+	network := make([]Room, 0, len(farm.Network)-1)
+	moved := make(map[*Room]*Room, len(farm.Network)-1)
+	for i := range farm.Network {
+		if i != index {
+			network = append(network, farm.Network[i])
+		}
+	}
+	for i, j := 0, 0; i < len(farm.Network); i++ {
+		if i != index {
+			moved[&farm.Network[i]] = &network[j]
+			j++
+		}
+	}
+	remap := func(rooms []*Room) []*Room {
+		output := make([]*Room, 0, len(rooms))
+		for _, room := range rooms {
+			if room != removed {
+				output = append(output, moved[room])
+			}
+		}
+		return output
+	}
+
+	networkMap := make(map[string][]*Room, len(network))
+	for i := range network {
+		room := &network[i]
+		room.Links = remap(room.Links)
+		if room.Weights != nil {
+			weights := make(map[*Room]int, len(room.Weights))
+			for link, weight := range room.Weights {
+				if link != removed {
+					weights[moved[link]] = weight
+				}
+			}
+			room.Weights = weights
+		}
+		networkMap[room.Name] = remap(farm.NetworkMap[room.Name])
+	}
+
+	farm.Network, farm.NetworkMap = network, networkMap
+	farm.Starts, farm.Ends = remap(farm.Starts), remap(farm.Ends)
+	farm.Start, farm.End = farm.Starts[0], farm.Ends[0]
+	farm.TotalRoomNbr--
+	if removed.Class == "start" && removed.Ants > 0 {
+		farm.TotalAntNbr -= removed.Ants
+	}
+	return nil
+}
+
+/*
+SetAnts sets the TotalAntNbr of the Farm (as read by readAnts). A non-nil error is returned, and the number
+of ants left unchanged, if it is not between 1 and MaxAnts, or if the start rooms are given their own numbers
+of ants (see HasStartAnts), which would no longer add up.
+*/
+func (farm *Farm) SetAnts(ants int) error {
+	if ants <= 0 || ants > MaxAnts {
+		return newParseError(ErrAntCount, "number of ants must be an integer between 1 and "+strconv.Itoa(MaxAnts))
+	} else if farm.HasStartAnts() && ants != farm.TotalAntNbr {
+		return newParseError(ErrStartAnts, "number of ants given for each start room ( ##start N ), "+
+			"the total can not be changed")
+	}
+	farm.TotalAntNbr = ants
+	return nil
+}
@@ -0,0 +1,118 @@
+package sys
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddLink(t *testing.T) {
+	farm, err := Parse([]string{"3", "##start", "a 0 0", "b 1 1", "##end", "c 2 2", "a-b"})
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error"+
+			"\ngot: %v", err)
+	}
+	if err = farm.AddLink("b-c", 3); err != nil {
+		t.Errorf("\nfunction AddLink returning unexpected error for valid link"+
+			"\ngot: %v", err)
+	}
+	b, c := &farm.Network[1], &farm.Network[2]
+	if len(b.Links) != 2 || b.Links[1] != c || len(c.Links) != 1 || c.Links[0] != b ||
+		b.Weights[c] != 3 || len(farm.NetworkMap["c"]) != 1 {
+		t.Errorf("\nfunction AddLink not writing expected link and weight"+
+			"\ngot links: %v, %v \nweight: %v", b.Links, c.Links, b.Weights[c])
+	}
+
+	invalid := []string{"b-c", "a-a", "a-x", "a-b-c"}
+	correctKind := []ErrorKind{ErrDuplicateLink, ErrSelfLink, ErrUnknownRoom, ErrLinkFormat}
+	for i, linkLine := range invalid {
+		if err = farm.AddLink(linkLine, 1); !errors.Is(err, correctKind[i]) {
+			t.Errorf("\nfunction AddLink not returning expected error for link \" %v \""+
+				"\ngot: %v \nexpected: %v", linkLine, err, correctKind[i])
+		}
+	}
+}
+
+func TestRemoveRoom(t *testing.T) {
+	farm, err := Parse([]string{"3", "##start", "a 0 0", "b 1 1", "d 1 2", "##end", "c 2 2", "#weight 2", "a-b",
+		"b-c", "a-d", "d-c"})
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error"+
+			"\ngot: %v", err)
+	}
+	if err = farm.RemoveRoom("b"); err != nil {
+		t.Fatalf("\nfunction RemoveRoom returning unexpected error"+
+			"\ngot: %v", err)
+	}
+
+	// Every pointer must lead into the new Network
+	a, d, c := &farm.Network[0], &farm.Network[1], &farm.Network[2]
+	if len(farm.Network) != 3 || farm.TotalRoomNbr != 3 || a.Name != "a" || d.Name != "d" || c.Name != "c" {
+		t.Errorf("\nfunction RemoveRoom not writing expected rooms"+
+			"\ngot: %v", farm.Network)
+	} else if farm.Start != a || farm.End != c || farm.Starts[0] != a || farm.Ends[0] != c {
+		t.Errorf("\nfunction RemoveRoom not writing expected start and end rooms"+
+			"\ngot: %v, %v", farm.Start.Name, farm.End.Name)
+	} else if len(a.Links) != 1 || a.Links[0] != d || len(c.Links) != 1 || c.Links[0] != d || len(d.Links) != 2 ||
+		farm.NetworkMap["a"][0] != d || len(farm.NetworkMap["c"]) != 1 {
+		t.Errorf("\nfunction RemoveRoom not writing expected links"+
+			"\ngot: %v, %v, %v", a.Links, d.Links, c.Links)
+	} else if len(a.Weights) != 0 {
+		t.Errorf("\nfunction RemoveRoom keeping weight of removed link"+
+			"\ngot: %v", a.Weights)
+	}
+
+	if err = farm.RemoveRoom("x"); !errors.Is(err, ErrUnknownRoom) {
+		t.Errorf("\nfunction RemoveRoom not returning expected error for unknown room"+
+			"\ngot: %v", err)
+	}
+	if err = farm.RemoveRoom("a"); !errors.Is(err, ErrNoStart) {
+		t.Errorf("\nfunction RemoveRoom not returning expected error for only start room"+
+			"\ngot: %v", err)
+	}
+	if err = farm.RemoveRoom("c"); !errors.Is(err, ErrNoEnd) {
+		t.Errorf("\nfunction RemoveRoom not returning expected error for only end room"+
+			"\ngot: %v", err)
+	}
+
+	// The ants of a removed start room are removed along with it
+	farm, err = Parse([]string{"5", "##multi", "##start 3", "s1 0 0", "##start 2", "s2 0 1", "##end", "e 2 0",
+		"s1-e", "s2-e"})
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error"+
+			"\ngot: %v", err)
+	}
+	if err = farm.RemoveRoom("s1"); err != nil || farm.TotalAntNbr != 2 || len(farm.Starts) != 1 ||
+		farm.Start.Name != "s2" {
+		t.Errorf("\nfunction RemoveRoom not removing start room with its ants"+
+			"\ngot: %v, %v ants, %v", err, farm.TotalAntNbr, farm.Starts)
+	}
+}
+
+func TestSetAnts(t *testing.T) {
+	farm, err := Parse([]string{"3", "##start", "a 0 0", "##end", "c 2 2", "a-c"})
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error"+
+			"\ngot: %v", err)
+	}
+	if err = farm.SetAnts(7); err != nil || farm.TotalAntNbr != 7 {
+		t.Errorf("\nfunction SetAnts not setting expected number of ants"+
+			"\ngot: %v, %v", farm.TotalAntNbr, err)
+	}
+	for _, ants := range []int{0, -1, MaxAnts + 1} {
+		if err = farm.SetAnts(ants); !errors.Is(err, ErrAntCount) || farm.TotalAntNbr != 7 {
+			t.Errorf("\nfunction SetAnts not returning expected error for %v ants"+
+				"\ngot: %v, %v", ants, farm.TotalAntNbr, err)
+		}
+	}
+
+	farm, err = Parse([]string{"5", "##multi", "##start 3", "s1 0 0", "##start 2", "s2 0 1", "##end", "e 2 0",
+		"s1-e", "s2-e"})
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error"+
+			"\ngot: %v", err)
+	}
+	if err = farm.SetAnts(6); !errors.Is(err, ErrStartAnts) || farm.TotalAntNbr != 5 {
+		t.Errorf("\nfunction SetAnts not returning expected error for start rooms with their own ants"+
+			"\ngot: %v, %v", farm.TotalAntNbr, err)
+	}
+}
@@ -0,0 +1,174 @@
+package sys
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*
+ErrorKind identifies the category of a ParseError, so that callers can tell errors in the input apart
+(e.g. a duplicate room from a link to an unknown room) with errors.Is, without comparing error messages:
+
+	if errors.Is(err, sys.ErrDuplicateRoom) { ... }
+
+An ErrorKind is itself an error, whose message is a short description of the category.
+*/
+type ErrorKind int
+
+const (
+	ErrFile            ErrorKind = iota + 1 // Input file / stream could not be found, read, or is empty
+	ErrLineFormat                           // Line does not match any valid input format
+	ErrNoAnts                               // No number of ants found
+	ErrAntCount                             // Number of ants not a positive integer, or exceeding MaxAnts
+	ErrMultipleAnts                         // More than one number of ants found
+	ErrNoStart                              // No ##start label found
+	ErrNoEnd                                // No ##end label found
+	ErrMultipleStart                        // More than one ##start label found
+	ErrMultipleEnd                          // More than one ##end label found
+	ErrMissingRoom                          // ##start / ##end label not followed by a room
+	ErrTooFewRooms                          // Less than 2 rooms found
+	ErrRoomFormat                           // Room line poorly formatted (name / class)
+	ErrCoords                               // Room coordinates missing, too long or unreadable
+	ErrDuplicateRoom                        // Two rooms with the same name
+	ErrDuplicateCoords                      // Two rooms with the same coordinates
+	ErrLinkFormat                           // Link line poorly formatted
+	ErrSelfLink                             // Room linked to itself
+	ErrUnknownRoom                          // Link to a room which does not exist
+	ErrDuplicateLink                        // Same link given more than once
+	ErrNoLinks                              // No links found
+	ErrCapacity                             // Room capacity (#capacity N) invalid or not followed by a room
+	ErrWeight                               // Tunnel weight (#weight N) invalid or not followed by a link
+	ErrStartAnts                            // Start room ant counts (##start N) invalid or not adding up
+)
+
+var errorKindNames = map[ErrorKind]string{
+	ErrFile:            "input file error",
+	ErrLineFormat:      "incorrectly formatted line",
+	ErrNoAnts:          "no ant input",
+	ErrAntCount:        "invalid number of ants",
+	ErrMultipleAnts:    "multiple ant inputs",
+	ErrNoStart:         "no start room",
+	ErrNoEnd:           "no end room",
+	ErrMultipleStart:   "multiple start rooms",
+	ErrMultipleEnd:     "multiple end rooms",
+	ErrMissingRoom:     "missing start / end room entry",
+	ErrTooFewRooms:     "too few rooms",
+	ErrRoomFormat:      "incorrectly formatted room",
+	ErrCoords:          "invalid room coordinates",
+	ErrDuplicateRoom:   "duplicate room",
+	ErrDuplicateCoords: "duplicate room coordinates",
+	ErrLinkFormat:      "incorrectly formatted link",
+	ErrSelfLink:        "room links to itself",
+	ErrUnknownRoom:     "link to unknown room",
+	ErrDuplicateLink:   "duplicate link",
+	ErrNoLinks:         "no links",
+	ErrCapacity:        "invalid room capacity",
+	ErrWeight:          "invalid tunnel weight",
+	ErrStartAnts:       "invalid start room ant count",
+}
+
+/*
+Error returns the short description of the ErrorKind (e.g. "duplicate room").
+*/
+func (kind ErrorKind) Error() string {
+	if name, found := errorKindNames[kind]; found {
+		return name
+	}
+	return "unknown error kind " + strconv.Itoa(int(kind))
+}
+
+/*
+ParseError is the error type returned by Parse, Setup and SetupFromReader for invalid input. It holds
+the Kind of error, the 1-based number (Line) and raw text (Text) of the offending input line (Line is 0
+if the error does not concern a single line, e.g. a missing ##start label), the 1-based Column of the
+offending token within the line (e.g. a coordinate, or a room name of a link), or of the start of the
+line if the whole line is at fault (0 along with Line), and a Message with further details. A
+*ParseError matches its Kind with errors.Is, and can be retrieved with errors.As.
+*/
+type ParseError struct {
+	Kind    ErrorKind
+	Line    int
+	Column  int
+	Text    string
+	Message string
+	token   int // 1-based index of the offending token of the line (see lineTokens), 0 if the whole line
+}
+
+/*
+newParseError returns a *ParseError of the given kind and message, without line information. The line
+number, column and text are filled in by the caller reading the input lines, with atLine.
+*/
+func newParseError(kind ErrorKind, message string) *ParseError {
+	return &ParseError{Kind: kind, Message: message}
+}
+
+/*
+atToken records the 1-based index of the offending token of the line (see lineTokens) in the ParseError,
+from which atLine finds its Column. The ParseError is returned.
+*/
+func (err *ParseError) atToken(token int) *ParseError {
+	err.token = token
+	return err
+}
+
+/*
+Error renders the ParseError in the format required by the project ("ERROR: invalid data format, ..."),
+followed by the line number, column and text of the offending input line, if known.
+*/
+func (err *ParseError) Error() string {
+	output := "\nERROR: invalid data format, " + err.Message
+	if err.Line > 0 {
+		output += "\nline " + strconv.Itoa(err.Line) + ", column " + strconv.Itoa(err.Column) + ": " + err.Text
+	}
+	return output
+}
+
+/*
+Is reports whether the target is the ErrorKind of the ParseError, for use with errors.Is.
+*/
+func (err *ParseError) Is(target error) bool {
+	kind, ok := target.(ErrorKind)
+	return ok && kind == err.Kind
+}
+
+/*
+atLine takes an error returned while reading the line at (0-based) index "i" of the input, along with
+the text of the line. If the error is a *ParseError without line information, the 1-based line number
+and the text are written to it, along with the column of its offending token (see lineTokens), or of the
+first character of the line if no token was recorded. A token missing from the line (e.g. a coordinate)
+is placed just after its last character. The error is returned.
+*/
+func atLine(err error, i int, line string) error {
+	if parseErr, ok := err.(*ParseError); ok && parseErr.Line == 0 {
+		parseErr.Line = i + 1
+		parseErr.Text = line
+		columns := lineTokens(line)
+		if parseErr.token > len(columns) {
+			parseErr.Column = len(strings.TrimRightFunc(line, isWhitespace)) + 1
+		} else if parseErr.token > 0 {
+			parseErr.Column = columns[parseErr.token-1]
+		} else {
+			parseErr.Column = len(line) - len(strings.TrimLeftFunc(line, isWhitespace)) + 1
+		}
+	}
+	return err
+}
+
+/*
+lineTokens returns the 1-based columns at which the tokens of an input line start: the two room names
+of a link line (split at the hyphen, see RegexLink), or the whitespace-separated fields of any other line
+(e.g. the name and coordinates of a room line).
+*/
+func lineTokens(line string) []int {
+	isLink := RegexLink.MatchString(line)
+	var columns []int
+	inToken := false
+	for i, letter := range line {
+		separator := isWhitespace(letter) || (isLink && letter == '-')
+		if !separator && !inToken {
+			columns = append(columns, i+1)
+		}
+		inToken = !separator
+	}
+	return columns
+}
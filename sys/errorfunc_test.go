@@ -0,0 +1,64 @@
+package sys
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	// Establish test variables
+	inputs := [][]string{
+		{"3", "##start", "a 0 0", "b 1 1", "##end", "c 2 2", "a-b", "b-c", "a 3 3"},
+		{"3", "##start", "a 0 0", "b 1 1", "##end", "c 2 2", "a-b", "b-b"},
+		{"3", "a 0 0", "b 1 1", "##end", "c 2 2", "a-b", "b-c"},
+		{"#comment", "0", "##start", "a 0 0", "##end", "c 2 2", "a-c"},
+		{"3", "##start", "a 0 0", "##end", "c 2 2", "a-d"},
+		{"3", "##start", "a 0 0", "##end", "c 2 2", "a-c", "a-c"},
+		{"3", "##start", "a 0 0", "##end", "c 2 2", "a-c", "a - c - d"},
+		{"3", "##start", "a 0 0", "##end", "c 2 2"},
+		{"3", "##start", "a 0 0", "b 1 12345678901", "##end", "c 2 2", "a-c"},
+		{"3", "##start", "a 0 0", "b  0 0", "##end", "c 2 2", "a-c"},
+		{"3", "##start", "a 0 0", "##end", "c 2 2", " a -  x"},
+		{"3", "##start", "#capacity 2 3", "a 0 0", "##end", "c 2 2", "a-c"},
+		{"3", "##start", "a 0 0", "##end", "c 2 2", "#weight x", "a-c"},
+	}
+	correctKind := []ErrorKind{ErrDuplicateRoom, ErrSelfLink, ErrNoStart, ErrAntCount, ErrUnknownRoom,
+		ErrDuplicateLink, ErrLineFormat, ErrNoLinks, ErrCoords, ErrDuplicateCoords, ErrUnknownRoom, ErrCapacity,
+		ErrWeight}
+	correctLine := []int{9, 8, 0, 2, 6, 7, 7, 0, 4, 4, 6, 3, 6}
+	// Column of the offending token (a room name of a link, a coordinate, the value of a directive), or of
+	// the start of the line
+	correctColumn := []int{1, 3, 0, 1, 3, 1, 1, 0, 5, 4, 7, 13, 9}
+
+	for i, input := range inputs {
+		_, err := Parse(input)
+		var parseErr *ParseError
+		if !errors.Is(err, correctKind[i]) {
+			t.Errorf("\nfunction Parse not returning expected error kind for input %v"+
+				"\ngot: %v \nexpected: %v", i, err, correctKind[i])
+		} else if !errors.As(err, &parseErr) || parseErr.Line != correctLine[i] {
+			t.Errorf("\nfunction Parse not returning expected line number for input %v"+
+				"\ngot: %v \nexpected: %v", i, err, correctLine[i])
+		} else if parseErr.Column != correctColumn[i] {
+			t.Errorf("\nfunction Parse not returning expected column for input %v"+
+				"\ngot: %v \nexpected: %v", i, parseErr.Column, correctColumn[i])
+		} else if parseErr.Line > 0 && parseErr.Text != input[parseErr.Line-1] {
+			t.Errorf("\nfunction Parse not returning expected line text for input %v"+
+				"\ngot: %v \nexpected: %v", i, parseErr.Text, input[parseErr.Line-1])
+		} else if !strings.HasPrefix(err.Error(), "\nERROR: invalid data format") {
+			t.Errorf("\nParseError not rendering the \" ERROR: invalid data format \" prefix"+
+				"\ngot: %v", err.Error())
+		}
+		if parseErr != nil && parseErr.Line > 0 &&
+			!strings.Contains(err.Error(), "line "+strconv.Itoa(parseErr.Line)+", column "+strconv.Itoa(parseErr.Column)) {
+			t.Errorf("\nParseError not rendering its line and column"+
+				"\ngot: %v", err.Error())
+		}
+		// Errors of a different kind must not match
+		if errors.Is(err, ErrFile) {
+			t.Errorf("\nParseError matching unrelated error kind for input %v \ngot: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package sys
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strings"
+)
+
+/*
+examples holds the example files of ./sys/examples, built into the program so that they can be used
+from any working directory.
+*/
+//go:embed examples/*.txt
+var examples embed.FS
+
+/*
+ExampleNames returns the names of the built-in example files (e.g. "example00"), in alphabetical order.
+*/
+func ExampleNames() []string {
+	entries, err := examples.ReadDir("examples")
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".txt"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+readExample reads the named built-in example file (with or without its .txt extension) into a slice of
+strings, one per line. A non-nil error, listing the available examples, is returned if no example file
+has the given name.
+*/
+func readExample(name string) ([]string, error) {
+	data, errRead := examples.ReadFile(path.Join("examples", strings.TrimSuffix(name, ".txt")+".txt"))
+	if errRead != nil || strings.ContainsAny(name, "/\\") {
+		return nil, newParseError(ErrFile, "no example file named \" "+name+" \" found \nexpected one of: "+
+			strings.Join(ExampleNames(), ", "))
+	}
+	return splitLines(data)
+}
+
+/*
+SetupExample is the equivalent of Setup for the built-in example files (see ExampleNames): the named
+example is read and passed to Parse. The resulting Farm is returned, along with a non-nil error if there
+is no such example, or if any errors with the input are found.
+*/
+func SetupExample(name string) (*Farm, error) {
+	fileContents, errRead := readExample(name)
+	if errRead != nil {
+		return nil, errRead
+	}
+	return Parse(fileContents)
+}
+
+/*
+LintExample is the equivalent of LintFile for the built-in example files: the named example is read and
+its lines passed to Lint. A non-nil error is returned if there is no such example.
+*/
+func LintExample(name string) ([]*ParseError, error) {
+	fileContents, errRead := readExample(name)
+	if errRead != nil {
+		return nil, errRead
+	}
+	return Lint(fileContents), nil
+}
@@ -0,0 +1,66 @@
+package sys
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupExample(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("examples", "*.txt"))
+	if err != nil || len(ExampleNames()) != len(files) {
+		t.Fatalf("\nfunction ExampleNames not listing every example file \ngot: %v \nexpected: %v",
+			ExampleNames(), files)
+	}
+
+	// Test examples by name, with and without the .txt extension
+	for _, name := range []string{"example00", "example00.txt"} {
+		if farm, err := SetupExample(name); err != nil || farm.TotalAntNbr != 4 {
+			t.Errorf("\nfunction SetupExample not reading example %v \ngot: %v", name, err)
+		}
+	}
+	if _, err = SetupExample("badexample00"); err == nil {
+		t.Errorf("\nfunction SetupExample not returning the errors of an invalid example")
+	}
+	if errs, err := LintExample("badexample00"); err != nil || len(errs) == 0 {
+		t.Errorf("\nfunction LintExample not returning the errors of an invalid example \ngot: %v, %v", errs, err)
+	}
+	for _, name := range []string{"example99", "../examples/example00", ""} {
+		if _, err = SetupExample(name); err == nil || !strings.Contains(err.Error(), "example00") {
+			t.Errorf("\nfunction SetupExample not listing the examples for unknown example %q \ngot: %v", name, err)
+		}
+	}
+}
+
+func TestSetupPaths(t *testing.T) {
+	// Test files without .txt extension, by absolute and relative path, from another working directory
+	data, err := os.ReadFile(filepath.Join("examples", "example00.txt"))
+	if err != nil {
+		t.Fatalf("\nerror in reading example file \ngot: %v", err)
+	}
+	dir := t.TempDir()
+	if err = os.WriteFile(filepath.Join(dir, "farm.map"), data, 0644); err != nil {
+		t.Fatalf("\nerror in writing test file \ngot: %v", err)
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("\nerror in finding working directory \ngot: %v", err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatalf("\nerror in changing working directory \ngot: %v", err)
+	}
+	defer os.Chdir(workingDir)
+
+	for _, fileName := range []string{filepath.Join(dir, "farm.map"), "farm.map", "./farm.map"} {
+		if farm, err := Setup(fileName); err != nil || farm.TotalAntNbr != 4 {
+			t.Errorf("\nfunction Setup not reading file %v \ngot: %v", fileName, err)
+		}
+	}
+	if _, err = Setup("example00.txt"); err == nil {
+		t.Errorf("\nfunction Setup still finding example files outside of the working directory")
+	}
+	if _, err = Setup(" "); err == nil {
+		t.Errorf("\nfunction Setup not returning error for empty file name")
+	}
+}
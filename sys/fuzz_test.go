@@ -0,0 +1,147 @@
+package sys
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+addExampleCorpus adds the contents of every file in ./examples to the seed corpus of a fuzz target,
+as one string per file, or one string per line if "perLine" is true.
+*/
+func addExampleCorpus(f *testing.F, perLine bool) {
+	files, err := filepath.Glob(filepath.Join("examples", "*.txt"))
+	if err != nil || len(files) == 0 {
+		f.Fatalf("\nerror in reading example files for seed corpus \ngot: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatalf("\nerror in reading example file %v \ngot: %v", file, err)
+		}
+		if !perLine {
+			f.Add(string(data))
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			f.Add(line)
+		}
+	}
+}
+
+/*
+checkFarmInvariants returns a description of the first broken invariant of a successfully parsed
+Farm, or an empty string if none is broken: Start and End rooms are set and within the Network, all
+links point to rooms within the Network, links are symmetric, there are no self-links and the
+NetworkMap holds the same links as the Network.
+*/
+func checkFarmInvariants(farm *Farm) string {
+	if farm.Start == nil || farm.End == nil {
+		return "Start and / or End room not set"
+	} else if farm.Start.Class != "start" || farm.End.Class != "end" {
+		return "Start and / or End room with wrong class"
+	} else if farm.TotalAntNbr <= 0 || farm.TotalAntNbr > MaxAnts {
+		return "number of ants out of range"
+	}
+
+	inNetwork := make(map[*Room]bool, len(farm.Network))
+	for i := range farm.Network {
+		inNetwork[&farm.Network[i]] = true
+	}
+	if !inNetwork[farm.Start] || !inNetwork[farm.End] {
+		return "Start and / or End room not in Network"
+	}
+	for i := range farm.Network {
+		room := &farm.Network[i]
+		if len(room.Links) != len(farm.NetworkMap[room.Name]) {
+			return "Network and NetworkMap links differ for room " + room.Name
+		}
+		for j, link := range room.Links {
+			if link == room {
+				return "self-link for room " + room.Name
+			} else if !inNetwork[link] {
+				return "link to room outside of Network for room " + room.Name
+			} else if farm.NetworkMap[room.Name][j] != link {
+				return "Network and NetworkMap links differ for room " + room.Name
+			}
+			symmetric := false
+			for _, back := range link.Links {
+				symmetric = symmetric || back == room
+			}
+			if !symmetric {
+				return "asymmetric link between rooms " + room.Name + " and " + link.Name
+			}
+		}
+	}
+	return ""
+}
+
+func FuzzParse(f *testing.F) {
+	addExampleCorpus(f, false)
+	f.Add("3\n##start\na 0 0\n##end\nb 1 1\na-b\n")
+	f.Add("3\r\n##start\r\n a  0  0 \r\n##end\r\nb 1 1\r\n a - b \r\n")
+
+	f.Fuzz(func(t *testing.T, data string) {
+		fileContents, err := splitLines([]byte(data))
+		if err != nil {
+			return
+		}
+		farm, errParse := Parse(fileContents)
+		errs := Lint(fileContents)
+		if errParse != nil {
+			if len(errs) == 0 {
+				t.Errorf("\nfunction Lint returning no errors for input rejected by Parse"+
+					"\nParse error: %v", errParse)
+			}
+			return
+		}
+		if broken := checkFarmInvariants(farm); broken != "" {
+			t.Errorf("\nfunction Parse returning a Farm with broken invariant: %v", broken)
+		}
+	})
+}
+
+func FuzzParseLinks(f *testing.F) {
+	addExampleCorpus(f, true)
+	f.Add(" a - b ")
+	f.Add("a -b")
+	f.Add("a- ")
+
+	f.Fuzz(func(t *testing.T, line string) {
+		output, err := parseLinks(line)
+		if err != nil {
+			return
+		}
+		if len(output) != 2 {
+			t.Fatalf("\nfunction parseLinks not returning exactly 2 room names \ngot: %q", output)
+		}
+		for _, name := range output {
+			if name == "" || strings.ContainsAny(name, "- \t") {
+				t.Errorf("\nfunction parseLinks returning an invalid room name \ngot: %q", output)
+			}
+		}
+	})
+}
+
+func FuzzParseRoom(f *testing.F) {
+	addExampleCorpus(f, true)
+	f.Add("  a  1   2  ")
+	f.Add("a 12345678901 2")
+
+	f.Fuzz(func(t *testing.T, line string) {
+		farm := &Farm{NetworkMap: make(map[string][]*Room), TotalRoomNbr: 2}
+		room, err := farm.parseRoom(line, "intermediate")
+		if err != nil {
+			return
+		}
+		if !RegexString.MatchString(room.Name) || !strings.Contains(line, room.Name) {
+			t.Errorf("\nfunction parseRoom returning an invalid room name \ngot: %q", room.Name)
+		} else if len(room.Coords) != 2 {
+			t.Errorf("\nfunction parseRoom not returning exactly 2 coordinates \ngot: %v", room.Coords)
+		} else if _, found := farm.NetworkMap[room.Name]; !found {
+			t.Errorf("\nfunction parseRoom not writing room %v to NetworkMap", room.Name)
+		}
+	})
+}
@@ -0,0 +1,292 @@
+package sys
+
+import (
+	"io"
+	"sort"
+	"strconv"
+)
+
+/*
+lintAnts is the collecting counterpart of readAnts. It checks the input lines for the number of ants,
+and returns every error found (multiple ant inputs, invalid or missing number of ants) instead of
+stopping at the first one.
+*/
+func lintAnts(fileContents []string) []*ParseError {
+	var errs []*ParseError
+	var antIndices []int
+
+	for i, line := range fileContents {
+		if !RegexComment.MatchString(line) && RegexAnts.MatchString(line) {
+			antIndices = append(antIndices, i)
+		}
+	}
+	// Every ant input after the first one is reported, and the lines up to the second
+	// ant input are checked by readAnts (which stops at the first repeated ant input)
+	end := len(fileContents)
+	for j := 1; j < len(antIndices); j++ {
+		errs = append(errs, atLine(newParseError(ErrMultipleAnts, "multiple ant inputs detected"),
+			antIndices[j], fileContents[antIndices[j]]).(*ParseError))
+		if j == 1 {
+			end = antIndices[j]
+		}
+	}
+	farm := &Farm{}
+	if err := farm.readAnts(fileContents[:end]); err != nil {
+		errs = append(errs, err.(*ParseError))
+	}
+	return errs
+}
+
+/*
+lintLabels is the collecting counterpart of countRooms. It returns an error for every repeated ##start /
+##end label (unless the Farm allows several, see Multi), for missing labels and for less than 2 room
+entries. It also returns the number of room entries found.
+*/
+func (farm *Farm) lintLabels(fileContents []string) ([]*ParseError, int) {
+	var errs []*ParseError
+	count := 0
+	startLabel := false
+	endLabel := false
+
+	for i, line := range fileContents {
+		class, _, _ := farm.readLabel(line)
+		if class == "start" && (!startLabel || farm.Multi) {
+			startLabel = true
+		} else if class == "start" {
+			errs = append(errs, atLine(newParseError(ErrMultipleStart, "multiple start room labels ( ##start ) "+
+				"detected (several start rooms require a ##multi line)"), i, line).(*ParseError))
+		} else if class == "end" && (!endLabel || farm.Multi) {
+			endLabel = true
+		} else if class == "end" {
+			errs = append(errs, atLine(newParseError(ErrMultipleEnd, "multiple end room labels ( ##end ) "+
+				"detected (several end rooms require a ##multi line)"), i, line).(*ParseError))
+		} else if RegexRoom.MatchString(line) {
+			count++
+		}
+	}
+
+	if !startLabel {
+		errs = append(errs, newParseError(ErrNoStart, "no start room label ( ##start ) found"))
+	}
+	if !endLabel {
+		errs = append(errs, newParseError(ErrNoEnd, "no end room label ( ##end ) found"))
+	}
+	if count < 2 {
+		errs = append(errs, newParseError(ErrTooFewRooms, "less than 2 valid room entries in input \ngot: "+
+			strconv.Itoa(count)))
+	}
+	return errs, count
+}
+
+/*
+lintRooms is the collecting counterpart of readRooms. Every room entry is parsed and written to the
+Network of the Farm, and an error is returned for every room which could not be parsed, has a duplicate
+name or duplicate coordinates, for ##start / ##end labels without a room entry, for invalid
+"#capacity N" lines (or those not followed by a room entry) and for invalid start room ant counts
+("##start N", see checkStartAnts). Rooms with duplicate coordinates are still written to the Network,
+so that links to them can be checked by lintLinks.
+*/
+func (farm *Farm) lintRooms(fileContents []string) []*ParseError {
+	var errs []*ParseError
+	startLabel := false
+	endLabel := false
+	capacity, capacityLine := 0, 0
+	startAnts := 0
+
+	for i, line := range fileContents {
+		if RegexCapacity.MatchString(line) {
+			if capacity > 0 {
+				errs = append(errs, atLine(newParseError(ErrCapacity,
+					"room capacity not followed by a room entry"), capacityLine, fileContents[capacityLine]).(*ParseError))
+			}
+			var errCapacity error
+			capacity, errCapacity = parseCapacity(line)
+			if errCapacity != nil {
+				errs = append(errs, atLine(errCapacity, i, line).(*ParseError))
+			}
+			capacityLine = i
+			continue
+		} else if RegexComment.MatchString(line) || RegexEmpty.MatchString(line) {
+			continue
+		} else if class, ants, errLabel := farm.readLabel(line); errLabel != nil {
+			errs = append(errs, atLine(errLabel, i, line).(*ParseError))
+			startLabel, startAnts = true, 0
+			continue
+		} else if farm.Multi && class != "" && (startLabel || endLabel) {
+			errs = append(errs, atLine(newParseError(ErrMissingRoom,
+				"no room entry discovered after start / end room label"), i, line).(*ParseError))
+			startLabel, endLabel = class == "start", class == "end"
+			startAnts = ants
+			continue
+		} else if class == "start" && !startLabel {
+			startLabel, startAnts = true, ants
+			continue
+		} else if class == "end" && !endLabel {
+			endLabel = true
+			continue
+		}
+
+		if startLabel && endLabel {
+			errs = append(errs, atLine(newParseError(ErrMissingRoom,
+				"no room entries discovered between start and end room labels"), i, line).(*ParseError))
+			startLabel, endLabel = false, false
+		}
+		if !RegexRoom.MatchString(line) {
+			if capacity > 0 {
+				errs = append(errs, atLine(newParseError(ErrCapacity,
+					"room capacity not followed by a room entry"), capacityLine, fileContents[capacityLine]).(*ParseError))
+				capacity = 0
+			}
+			continue
+		}
+
+		roomClass := "intermediate"
+		if startLabel {
+			roomClass = "start"
+			startLabel = false
+		} else if endLabel {
+			roomClass = "end"
+			endLabel = false
+		}
+		roomEntry, errRead := farm.parseRoom(line, roomClass)
+		if errRead != nil {
+			errs = append(errs, atLine(errRead, i, line).(*ParseError))
+			capacity = 0
+			continue
+		}
+		if roomClass == "start" {
+			roomEntry.Ants = startAnts
+		}
+		roomEntry.Capacity, capacity, errRead = applyCapacity(roomEntry, capacity)
+		if errRead != nil {
+			errs = append(errs, atLine(errRead, i, line).(*ParseError))
+		}
+		if errDuplicates := farm.checkRoomDuplicates(roomEntry); errDuplicates != nil {
+			errs = append(errs, atLine(errDuplicates, i, line).(*ParseError))
+		}
+		farm.Network = append(farm.Network, roomEntry)
+		farm.writeTerminal()
+	}
+
+	if startLabel || endLabel {
+		errs = append(errs, newParseError(ErrMissingRoom, "missing start and / or end room data entry"))
+	}
+	if errStartAnts := farm.checkStartAnts(); errStartAnts != nil && farm.TotalAntNbr > 0 {
+		errs = append(errs, errStartAnts.(*ParseError))
+	}
+	if capacity > 0 {
+		errs = append(errs, atLine(newParseError(ErrCapacity,
+			"room capacity not followed by a room entry"), capacityLine, fileContents[capacityLine]).(*ParseError))
+	}
+	return errs
+}
+
+/*
+lintLinks is the collecting counterpart of readLinks. Every link entry is parsed and written to the
+Network and NetworkMap of the Farm, and an error is returned for every poorly formatted link, link to an
+unknown room, self-link, duplicate link or misplaced tunnel weight, as well as if no links are found at all.
+*/
+func (farm *Farm) lintLinks(fileContents []string) []*ParseError {
+	var errs []*ParseError
+	linkCounter := 0
+	weight, weightLine := 0, 0
+
+	for i, line := range fileContents {
+		if RegexWeight.MatchString(line) {
+			if weight > 0 {
+				errs = append(errs, atLine(newParseError(ErrWeight,
+					"tunnel weight not followed by a link entry"), weightLine, fileContents[weightLine]).(*ParseError))
+			}
+			parsed, errWeight := parseWeight(line)
+			if errWeight != nil {
+				errs = append(errs, atLine(errWeight, i, line).(*ParseError))
+			}
+			weight, weightLine = parsed, i
+			continue
+		} else if !RegexLink.MatchString(line) {
+			if weight > 0 && !RegexComment.MatchString(line) && !RegexEmpty.MatchString(line) {
+				errs = append(errs, atLine(newParseError(ErrWeight,
+					"tunnel weight not followed by a link entry"), weightLine, fileContents[weightLine]).(*ParseError))
+				weight = 0
+			}
+			continue
+		}
+		linkCounter++
+		linkSlice, errParseLinks := parseLinks(line)
+		if errParseLinks != nil {
+			errs = append(errs, atLine(errParseLinks, i, line).(*ParseError))
+		} else if errWriteLinks := farm.writeLinks(linkSlice, weight); errWriteLinks != nil {
+			errs = append(errs, atLine(errWriteLinks, i, line).(*ParseError))
+		}
+		weight = 0
+	}
+	if linkCounter < 1 {
+		errs = append(errs, newParseError(ErrNoLinks, "no link input data found"))
+	}
+	if weight > 0 {
+		errs = append(errs, atLine(newParseError(ErrWeight,
+			"tunnel weight not followed by a link entry"), weightLine, fileContents[weightLine]).(*ParseError))
+	}
+	return errs
+}
+
+/*
+Lint is the collecting counterpart of Parse, for checking an input file in one go. Rather than stopping
+at the first error, it runs every check over the whole of the input lines (ants, labels, rooms, links
+and line formatting) and returns every error found, sorted by line number (errors which do not concern
+a single line, e.g. a missing ##start label, are listed last). An empty slice is returned for valid input.
+*/
+func Lint(fileContents []string) []*ParseError {
+	errs := lintAnts(fileContents)
+
+	farm := &Farm{Multi: isMulti(fileContents)}
+	labelErrs, count := farm.lintLabels(fileContents)
+	errs = append(errs, labelErrs...)
+
+	farm.Network = make([]Room, 0, count)
+	farm.NetworkMap = make(map[string][]*Room, count)
+	farm.TotalRoomNbr = count
+	if farm.readAnts(fileContents) != nil {
+		farm.TotalAntNbr = 0 // Numbers of ants of the start rooms are only checked against a valid number of ants
+	}
+	errs = append(errs, farm.lintRooms(fileContents)...)
+	errs = append(errs, farm.lintLinks(fileContents)...)
+
+	for i, line := range fileContents {
+		if !isValidLine(line) {
+			errs = append(errs, atLine(lineFormatError(line), i, line).(*ParseError))
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line == 0 || errs[j].Line == 0 {
+			return errs[j].Line == 0 && errs[i].Line != 0
+		}
+		return errs[i].Line < errs[j].Line
+	})
+	return errs
+}
+
+/*
+LintFile is the equivalent of Setup for Lint: the named file (path) is read and its lines passed to
+Lint. A non-nil error is returned if the file cannot be read.
+*/
+func LintFile(fileName string) ([]*ParseError, error) {
+	fileContents, errRead := readInputFile(fileName)
+	if errRead != nil {
+		return nil, errRead
+	}
+	return Lint(fileContents), nil
+}
+
+/*
+LintReader is the equivalent of SetupFromReader for Lint: the whole input is read from the io.Reader
+and its lines passed to Lint. A non-nil error is returned if the input cannot be read.
+*/
+func LintReader(reader io.Reader) ([]*ParseError, error) {
+	fileContents, errRead := readInput(reader)
+	if errRead != nil {
+		return nil, errRead
+	}
+	return Lint(fileContents), nil
+}
@@ -0,0 +1,55 @@
+package sys
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	// Establish test variables
+	valid := []string{"3", "##start", "a 0 0", "b 1 1", "##end", "c 2 2", "a-b", "b-c"}
+	invalid := []string{"3", "3", "##start", "a 0 0", "b 0 0", "##end", "c 2 2", "a-b", "b-b", "a-x",
+		"a-b", "foo bar", "##start"}
+	correctKind := []ErrorKind{ErrMultipleAnts, ErrDuplicateCoords, ErrSelfLink, ErrUnknownRoom,
+		ErrDuplicateLink, ErrLineFormat, ErrMultipleStart, ErrMissingRoom}
+	correctLine := []int{2, 5, 9, 10, 11, 12, 13, 0}
+	correctColumn := []int{1, 3, 3, 3, 1, 1, 1, 0}
+
+	if errs := Lint(valid); len(errs) != 0 {
+		t.Errorf("\nfunction Lint returning errors for valid input"+
+			"\ngot: %v", errs)
+	}
+
+	errs := Lint(invalid)
+	kinds := make([]ErrorKind, len(errs))
+	lines := make([]int, len(errs))
+	columns := make([]int, len(errs))
+	for i, err := range errs {
+		kinds[i], lines[i], columns[i] = err.Kind, err.Line, err.Column
+	}
+	if !reflect.DeepEqual(kinds, correctKind) || !reflect.DeepEqual(lines, correctLine) {
+		t.Errorf("\nfunction Lint not returning all expected errors in line order"+
+			"\ngot: %v %v \nexpected: %v %v", kinds, lines, correctKind, correctLine)
+	} else if !reflect.DeepEqual(columns, correctColumn) {
+		t.Errorf("\nfunction Lint not returning expected columns"+
+			"\ngot: %v \nexpected: %v", columns, correctColumn)
+	}
+
+	// Errors without a line are listed last
+	errs = Lint([]string{"a 0 0", "b 1 1", "a-b"})
+	if len(errs) == 0 || errs[0].Kind != ErrNoAnts || errs[len(errs)-1].Kind != ErrNoEnd {
+		t.Errorf("\nfunction Lint not returning expected errors for input without ants and labels"+
+			"\ngot: %v", errs)
+	}
+
+	// Reading input
+	errs, err := LintReader(strings.NewReader(strings.Join(valid, "\n")))
+	if err != nil || len(errs) != 0 {
+		t.Errorf("\nfunction LintReader returning errors for valid input"+
+			"\ngot: %v %v", errs, err)
+	}
+	if _, err = LintFile("nonexistent.txt"); err == nil {
+		t.Errorf("\nfunction LintFile not returning error for non-existent file")
+	}
+}
@@ -1,7 +1,7 @@
 package sys
 
 import (
-	"errors"
+	"io"
 	"os"
 	"reflect"
 	"regexp"
@@ -9,22 +9,51 @@ import (
 	"strings"
 )
 
+/*
+Farm holds everything parsed from one input file: the rooms (Network), their links by room name
+(NetworkMap), the room and ant totals, pointers to the start and end rooms within Network, and the
+original input lines. A Farm is returned by Parse / Setup and used as input for the routing package,
+so that several farms can be handled independently (and concurrently) within one process. Input files
+with a "##multi" line may have several start and end rooms, all of which are listed in Starts and Ends
+(Start and End then being the first of each).
+*/
+type Farm struct {
+	Network      []Room
+	NetworkMap   map[string][]*Room
+	TotalRoomNbr int
+	TotalAntNbr  int
+	Start        *Room
+	End          *Room
+	Starts       []*Room  // Every start room, in input order
+	Ends         []*Room  // Every end room, in input order
+	Multi        bool     // Several start and end rooms allowed, from a "##multi" line
+	FileContents []string // Original input lines, for printing
+}
+
 type Room struct {
-	Name    string
-	Class   string
-	Coords  []int // [x-coord, y-coord]
-	Links   []*Room
-	AntID   int // Initialised / reset to 0, to signify unoccupied. Any positive integer = occupied
-	Visited bool
-	Next    *Room
+	Name     string
+	Class    string
+	Coords   []int // [x-coord, y-coord]
+	Links    []*Room
+	AntID    int           // Initialised / reset to 0, to signify unoccupied. Any positive integer = occupied
+	Capacity int           // Maximum number of ants held at once, from a "#capacity N" line (0 = default of one ant)
+	Weights  map[*Room]int // Turns taken to reach linked rooms, from "#weight N" lines (missing = one turn)
+	Ants     int           // Ants starting in a start room, from a "##start N" line (0 = shared between start rooms)
+	Visited  bool
+	Next     *Room
 }
 
 var (
-	RegexFileName = regexp.MustCompile(`^.+\.txt\z`)
+	RegexFileName = regexp.MustCompile(`^.*\S.*\z`) // Any file path, with any (or no) extension
 	RegexComment  = regexp.MustCompile(`^#{1}[^#].*\z`)
+	RegexCapacity = regexp.MustCompile(`^#capacity(\s.*)?\z`) // Capacity of the next room, e.g. "#capacity 3"
+	RegexWeight   = regexp.MustCompile(`^#weight(\s.*)?\z`)   // Weight of the next link, e.g. "#weight 3"
 	RegexAnts     = regexp.MustCompile(`^\s*-?\d+\s*\z`)
 	RegexStart    = regexp.MustCompile(`^##start\s*\z`)
+	RegexStartAnt = regexp.MustCompile(`^##start\s+\S.*\z`) // Start room with its number of ants, e.g. "##start 5"
+	RegexMulti    = regexp.MustCompile(`^##multi\s*\z`)     // Several start and end rooms allowed
 	RegexEnd      = regexp.MustCompile(`^##end\s*\z`)
+	RegexCommand  = regexp.MustCompile(`^##.*\z`) // Any other command, which is ignored
 	RegexRoom     = regexp.MustCompile(`^\s*[a-zA-Z0-9]+\s+-?\d+\s+-?\d+\s*\z`)
 	RegexLink     = regexp.MustCompile(`^\s*[a-zA-Z0-9]+\s*-\s*[a-zA-Z0-9]+\s*\z`)
 	RegexInt      = regexp.MustCompile(`^\s*-?\d+\s*\z`)
@@ -33,121 +62,248 @@ var (
 	RegexEmpty    = regexp.MustCompile(`^\s*\z`)
 	RegexLinkChar = regexp.MustCompile(`^`)
 	MaxAnts       = int(1000000)
-	Network       = make([]Room, 0)          // FOR ROUTING FUNCTIONS
-	NetworkMap    = make(map[string][]*Room) // FOR ROUTING FUNCTIONS
-	TotalRoomNbr  = int(0)                   // FOR ROUTING FUNCTIONS
-	TotalAntNbr   = int(0)                   // FOR ROUTING FUNCTIONS
-	Start         *Room                      // FOR ROUTING FUNCTIONS
-	End           *Room                      // FOR ROUTING FUNCTIONS
 )
 
 /*
-findRoomIndex takes an input room name string, and searches the global Network variable ([]Room).
+Rule sets for moving ants, shared by the routing and verify packages. Under RulesRooms (the default) an
+intermediate room holds at most one ant at a time, so that routes may not share rooms (vertex-disjoint
+routes). Under RulesTunnels rooms may hold any number of ants, but no tunnel may be used by more than one
+ant in the same turn, so that routes may share rooms but not tunnels (edge-disjoint routes).
+*/
+const (
+	RulesRooms   = "rooms"
+	RulesTunnels = "tunnels"
+)
+
+/*
+AntCapacity returns the maximum number of ants the (intermediate) room can hold at once: its Capacity,
+or one if no capacity was given.
+*/
+func (room *Room) AntCapacity() int {
+	if room.Capacity < 1 {
+		return 1
+	}
+	return room.Capacity
+}
+
+/*
+HasCapacities returns true if any room of the Network of the Farm can hold more than one ant at once.
+*/
+func (farm *Farm) HasCapacities() bool {
+	for i := range farm.Network {
+		if farm.Network[i].AntCapacity() > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Weight returns the number of turns taken to cross the tunnel from the room to the linked room: one, unless
+the link was given a weight.
+*/
+func (room *Room) Weight(link *Room) int {
+	if weight, found := room.Weights[link]; found {
+		return weight
+	}
+	return 1
+}
+
+/*
+HasWeights returns true if any tunnel of the Network of the Farm takes more than one turn to cross.
+*/
+func (farm *Farm) HasWeights() bool {
+	for i := range farm.Network {
+		if len(farm.Network[i].Weights) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+HasStartAnts returns true if the number of ants starting in each start room of the Farm is given ("##start N").
+*/
+func (farm *Farm) HasStartAnts() bool {
+	for _, start := range farm.Starts {
+		if start.Ants > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+HasMultipleTerminals returns true if the Farm has more than one start room or more than one end room.
+*/
+func (farm *Farm) HasMultipleTerminals() bool {
+	return len(farm.Starts) > 1 || len(farm.Ends) > 1
+}
+
+/*
+parseDirective takes a "#<name> N" line (e.g. "#capacity 3") and returns the value N, along with a non-nil
+error of the given kind if N is not a single integer between 1 and MaxAnts. The label (e.g. "room capacity")
+is used in the error message.
+*/
+func parseDirective(line, label string, kind ErrorKind) (int, error) {
+	details := strings.Fields(line)
+	if len(details) > 2 {
+		return 0, newParseError(kind, label+" must be given as \" "+details[0]+" N \"").atToken(3)
+	} else if len(details) != 2 || !RegexIntLim.MatchString(details[1]) {
+		return 0, newParseError(kind, label+" must be given as \" "+details[0]+" N \"").atToken(2)
+	}
+	value, errAtoi := strconv.Atoi(details[1])
+	if errAtoi != nil || value < 1 || value > MaxAnts {
+		return 0, newParseError(kind, label+" must be an integer between 1 and "+strconv.Itoa(MaxAnts)).atToken(2)
+	}
+	return value, nil
+}
+
+/*
+parseCapacity takes a "#capacity N" line and returns the capacity N (see parseDirective).
+*/
+func parseCapacity(line string) (int, error) {
+	return parseDirective(line, "room capacity", ErrCapacity)
+}
+
+/*
+parseWeight takes a "#weight N" line and returns the weight N (see parseDirective).
+*/
+func parseWeight(line string) (int, error) {
+	return parseDirective(line, "tunnel weight", ErrWeight)
+}
+
+/*
+findRoomIndex takes an input room name string, and searches the Network ([]Room) of the Farm.
 It returns an integer equivalent to the index of the room with the matching Name, along with an
 error value which is non-nil in the event that the room could not be found.
 */
-func findRoomIndex(roomName string) (int, error) {
-	for i, roomInNetwork := range Network {
+func (farm *Farm) findRoomIndex(roomName string) (int, error) {
+	for i, roomInNetwork := range farm.Network {
 		if roomInNetwork.Name == roomName {
 			return i, nil
 		}
 	}
-	return -1, errors.New("\nERROR: invalid data format, room not found")
+	return -1, newParseError(ErrUnknownRoom, "room not found")
 }
 
 /*
-InitialiseMapKey takes a key string value and checks if it exists in the global NetworkMap map
-variable. If it exists, a non-nil error is returned. If it doesn't exist, the key is initialised
-along with a []string value with capacity equal to the TotalRoomNbr integer variable.
+InitialiseMapKey takes a key string value and checks if it exists in the NetworkMap of the
+Farm. If it exists, a non-nil error is returned. If it doesn't exist, the key is initialised
+along with a []*Room value with capacity equal to the TotalRoomNbr of the Farm minus one.
 */
-func initialiseMapKey(mapKey string) error {
-	if _, found := NetworkMap[mapKey]; !found {
-		NetworkMap[mapKey] = make([]*Room, 0, (TotalRoomNbr - 1))
+func (farm *Farm) initialiseMapKey(mapKey string) error {
+	if _, found := farm.NetworkMap[mapKey]; !found {
+		farm.NetworkMap[mapKey] = make([]*Room, 0, (farm.TotalRoomNbr - 1))
 	} else {
-		return errors.New("\nERROR: invalid data format, found duplicate room name: " +
-			mapKey)
+		return newParseError(ErrDuplicateRoom, "found duplicate room name: "+
+			mapKey).atToken(1)
 	}
 	return nil
 }
 
 /*
-WriteLinks reads an input []string containing the names of two linked rooms. It then writes these
-links to the global Network and NetworkMap variables for each respective room name / key. A non-nil
-error is returned in the event an invalid / non-existent room name is given, if not exactly
-two room names are provided in the input []string, if the two room names are the same (room links
+WriteLinks reads an input []string containing the names of two linked rooms, along with the weight of the
+link (the number of turns taken to cross it). It then writes these links to the Network and NetworkMap of
+the Farm for each respective room name / key, and a weight of more than one turn to the Weights of both
+rooms. A non-nil error is returned in the event an invalid / non-existent room name is given, if not
+exactly two room names are provided in the input []string, if the two room names are the same (room links
 to itself) or if the link already exists (duplicate).
 */
-func writeLinks(roomLinks []string) error {
+func (farm *Farm) writeLinks(roomLinks []string, weight int) error {
 	// Initial input error checks
 	if len(roomLinks) != 2 {
-		return errors.New("\nERROR: invalid data format, too many / few links provided in link input: " +
-			"\ninput: " + "[ " + strings.Join(roomLinks, " , ") + " ]")
+		return newParseError(ErrLinkFormat, "too many / few links provided in link input: "+
+			"\ninput: "+"[ "+strings.Join(roomLinks, " , ")+" ]").atToken(3)
 	} else if roomLinks[0] == roomLinks[1] {
-		return errors.New("\nERROR: invalid data format, room connects to itself in link input: " +
-			"\ninput: " + "[ " + strings.Join(roomLinks, " , ") + " ]")
-	} else if _, found := NetworkMap[roomLinks[0]]; !found {
-		return errors.New("\nERROR: invalid data format, input contains non-existent room name: " +
-			"\nroom name not found: " + "[ " + roomLinks[0] + " ]")
-	} else if _, found := NetworkMap[roomLinks[1]]; !found {
-		return errors.New("\nERROR: invalid data format, input contains non-existent room name: " +
-			"\nroom name not found: " + "[ " + roomLinks[1] + " ]")
+		return newParseError(ErrSelfLink, "room connects to itself in link input: "+
+			"\ninput: "+"[ "+strings.Join(roomLinks, " , ")+" ]").atToken(2)
+	} else if _, found := farm.NetworkMap[roomLinks[0]]; !found {
+		return newParseError(ErrUnknownRoom, "input contains non-existent room name: "+
+			"\nroom name not found: "+"[ "+roomLinks[0]+" ]").atToken(1)
+	} else if _, found := farm.NetworkMap[roomLinks[1]]; !found {
+		return newParseError(ErrUnknownRoom, "input contains non-existent room name: "+
+			"\nroom name not found: "+"[ "+roomLinks[1]+" ]").atToken(2)
 	}
 
 	roomIndex := -1
 	var errFindRoom error
-	// Write links to global variables
-	for i, roomInNetwork := range Network {
+	// Write links to Farm
+	for i, roomInNetwork := range farm.Network {
 		if roomInNetwork.Name == roomLinks[0] {
 			// WRITE LINKS FOR 1ST ROOM IN SLICE
-			roomIndex, errFindRoom = findRoomIndex(roomLinks[1])
+			roomIndex, errFindRoom = farm.findRoomIndex(roomLinks[1])
 			if errFindRoom != nil {
 				return errFindRoom
 			}
-			// Write to global Network variable
+			// Write to Network
 			for _, link := range roomInNetwork.Links {
 				if link.Name == roomLinks[1] {
-					return errors.New("\nERROR: invalid data format, link already exists in global Network variable (1):" +
-						"\ninput link: " + "[ " + roomLinks[0] + " , " + roomLinks[1] + " ]")
+					return newParseError(ErrDuplicateLink, "link already exists in Network (1):"+
+						"\ninput link: "+"[ "+roomLinks[0]+" , "+roomLinks[1]+" ]")
 				}
 			}
-			Network[i].Links = append(roomInNetwork.Links, &Network[roomIndex])
-			// Write to global NetworkMap variable
-			for _, link := range NetworkMap[roomLinks[0]] {
+			farm.Network[i].Links = append(roomInNetwork.Links, &farm.Network[roomIndex])
+			farm.Network[i].writeWeight(&farm.Network[roomIndex], weight)
+			// Write to NetworkMap
+			for _, link := range farm.NetworkMap[roomLinks[0]] {
 				if link.Name == roomLinks[1] {
-					return errors.New("\nERROR: invalid data format, link already exists in global NetworkMap variable (1):" +
-						"\ninput link: " + "[ " + roomLinks[0] + " , " + roomLinks[1] + " ]")
+					return newParseError(ErrDuplicateLink, "link already exists in NetworkMap (1):"+
+						"\ninput link: "+"[ "+roomLinks[0]+" , "+roomLinks[1]+" ]")
 				}
 			}
-			NetworkMap[roomLinks[0]] = append(NetworkMap[roomLinks[0]], &Network[roomIndex])
+			farm.NetworkMap[roomLinks[0]] = append(farm.NetworkMap[roomLinks[0]], &farm.Network[roomIndex])
 
 		} else if roomInNetwork.Name == roomLinks[1] {
 			// WRITE LINKS FOR 2ND ROOM IN SLICE
-			roomIndex, errFindRoom = findRoomIndex(roomLinks[0])
+			roomIndex, errFindRoom = farm.findRoomIndex(roomLinks[0])
 			if errFindRoom != nil {
 				return errFindRoom
 			}
-			// Write to global Newtork variable
+			// Write to Network
 			for _, link := range roomInNetwork.Links {
 				if link.Name == roomLinks[0] {
-					return errors.New("\nERROR: invalid data format, link already exists in global Network variable (2):" +
-						"\ninput link: " + "[ " + roomLinks[0] + " , " + roomLinks[1] + " ]")
+					return newParseError(ErrDuplicateLink, "link already exists in Network (2):"+
+						"\ninput link: "+"[ "+roomLinks[0]+" , "+roomLinks[1]+" ]")
 				}
 			}
-			Network[i].Links = append(roomInNetwork.Links, &Network[roomIndex])
-			// Write to global NetworkMap variable
-			for _, link := range NetworkMap[roomLinks[1]] {
+			farm.Network[i].Links = append(roomInNetwork.Links, &farm.Network[roomIndex])
+			farm.Network[i].writeWeight(&farm.Network[roomIndex], weight)
+			// Write to NetworkMap
+			for _, link := range farm.NetworkMap[roomLinks[1]] {
 				if link.Name == roomLinks[0] {
-					return errors.New("\nERROR: invalid data format, link already exists in global NetworkMap variable (2):" +
-						"\ninput link: " + "[ " + roomLinks[0] + " , " + roomLinks[1] + " ]")
+					return newParseError(ErrDuplicateLink, "link already exists in NetworkMap (2):"+
+						"\ninput link: "+"[ "+roomLinks[0]+" , "+roomLinks[1]+" ]")
 				}
 			}
-			NetworkMap[roomLinks[1]] = append(NetworkMap[roomLinks[1]], &Network[roomIndex])
+			farm.NetworkMap[roomLinks[1]] = append(farm.NetworkMap[roomLinks[1]], &farm.Network[roomIndex])
 		}
 
 	}
 	return nil
 }
 
+/*
+writeWeight writes the weight of the link from the room to the linked room to the Weights of the room, if
+it takes more than one turn to cross.
+*/
+func (room *Room) writeWeight(link *Room, weight int) {
+	if weight > 1 {
+		if room.Weights == nil {
+			room.Weights = make(map[*Room]int)
+		}
+		room.Weights[link] = weight
+	}
+}
+
+/*
+isWhitespace reports whether the input character is matched by " \s " in the regular expressions
+(space, tab, newline, form feed or carriage return).
+*/
+func isWhitespace(letter rune) bool {
+	return letter == ' ' || letter == '\t' || letter == '\n' || letter == '\f' || letter == '\r'
+}
+
 /*
 ParseLinks reads an input string and parses it for linked room values (sub-strings). If the
 format does not correspond to expected values (exactly two distinct strings), then a non-nil
@@ -161,9 +317,9 @@ func parseLinks(linkLine string) ([]string, error) {
 	if RegexLink.MatchString(linkLine) {
 		for i, letter := range linkLine {
 			if foundHyphen && letter == '-' {
-				return output, errors.New("\nERROR: invalid data format, more than one \" - \" discovered in input line" +
+				return output, newParseError(ErrLinkFormat, "more than one \" - \" discovered in input line"+
 					"\nrooms may not have \" - \" in their name, nor may multiple \" - \" be used for link inputs")
-			} else if !start && letter != '-' && letter != ' ' {
+			} else if !start && letter != '-' && !isWhitespace(letter) {
 				// Detect link start
 				start = true
 				startIndex = i
@@ -173,7 +329,7 @@ func parseLinks(linkLine string) ([]string, error) {
 					output = append(output, linkLine[startIndex:])
 					break
 				}
-			} else if start && (letter == '-' || letter == ' ') {
+			} else if start && (letter == '-' || isWhitespace(letter)) {
 				// Detect link end, record link
 				output = append(output, linkLine[startIndex:i])
 				start = false
@@ -190,39 +346,55 @@ func parseLinks(linkLine string) ([]string, error) {
 		}
 	} else {
 		// Regex match fails
-		return output, errors.New("\nERROR: invalid data format, link input poorly formatted" + "\ninput: " + linkLine)
+		return output, newParseError(ErrLinkFormat, "link input poorly formatted"+"\ninput: "+linkLine)
 	}
 	if len(output) != 2 || !foundHyphen {
 		// If output has not been filled correctly
-		return output, errors.New("\nERROR: invalid data format, incorrect link input format" +
-			"\nexactly 2 valid input room names, seperated by a hyphen, required in input string" +
-			"\ninput: " + linkLine)
+		return output, newParseError(ErrLinkFormat, "incorrect link input format"+
+			"\nexactly 2 valid input room names, seperated by a hyphen, required in input string"+
+			"\ninput: "+linkLine)
 	}
 	return output, nil
 }
 
 /*
 ReadLinks reads file contents in the form of an input slice of strings and checks the data for the
-specified room linkages. ReadLinks writes valid links to the global Network and NetworkMap variables.
-If an error in the input is found it is returned. Otherwise a nil value is returned.
+specified room linkages. ReadLinks writes valid links to the Network and NetworkMap of the Farm,
+with a "#weight N" line setting the weight of the link which follows it (comment lines may come in
+between). If an error in the input is found it is returned. Otherwise a nil value is returned.
 */
-func readLinks(fileContents []string) error {
+func (farm *Farm) readLinks(fileContents []string) error {
 	linkCounter := 0
-	for _, line := range fileContents {
-		if RegexLink.MatchString(line) {
+	weight := 0
+	var errWeight error
+	for i, line := range fileContents {
+		if RegexWeight.MatchString(line) {
+			if weight > 0 {
+				return atLine(newParseError(ErrWeight, "tunnel weight not followed by a link entry"), i, line)
+			}
+			weight, errWeight = parseWeight(line)
+			if errWeight != nil {
+				return atLine(errWeight, i, line)
+			}
+		} else if RegexLink.MatchString(line) {
 			linkCounter++
 			linkSlice, errParseLinks := parseLinks(line)
 			if errParseLinks != nil {
-				return errParseLinks
+				return atLine(errParseLinks, i, line)
 			}
-			errWriteLinks := writeLinks(linkSlice)
+			errWriteLinks := farm.writeLinks(linkSlice, weight)
 			if errWriteLinks != nil {
-				return errWriteLinks
+				return atLine(errWriteLinks, i, line)
 			}
+			weight = 0
+		} else if weight > 0 && !RegexComment.MatchString(line) && !RegexEmpty.MatchString(line) {
+			return atLine(newParseError(ErrWeight, "tunnel weight not followed by a link entry"), i, line)
 		}
 	}
 	if linkCounter < 1 {
-		return errors.New("\nERROR: invalid data format, no link input data found")
+		return newParseError(ErrNoLinks, "no link input data found")
+	} else if weight > 0 {
+		return newParseError(ErrWeight, "tunnel weight not followed by a link entry")
 	}
 	return nil
 }
@@ -239,7 +411,7 @@ func getRoomName(roomDetails []string) (string, int, error) {
 		}
 	}
 	// If no room name is found / matched
-	return "", 0, errors.New("\nERROR: invalid data format, found entry with no room name")
+	return "", 0, newParseError(ErrRoomFormat, "found entry with no room name")
 }
 
 /*
@@ -260,19 +432,23 @@ func getRoomCoords(roomDetails []string, roomName string, index int) ([]int, err
 		} else if RegexInt.MatchString(roomDetails[i]) {
 			// Check for possible overflow with excessively long coordinate
 			if !RegexIntLim.MatchString(roomDetails[i]) {
-				return coords, errors.New("\nERROR: invalid data format, coordinates may not exceed 10 digits \ngot:  " + roomDetails[i])
+				return coords, newParseError(ErrCoords, "coordinates may not exceed 10 digits \ngot:  "+
+					roomDetails[i]).atToken(i + 1)
 			}
 			// Record coordinates
 			nbr, errCoord = strconv.Atoi(roomDetails[i])
-			if errCoord != nil {
-				return coords, errors.New("\nERROR: invalid data format, problem in parsing coordinates for room \" " + roomName + " \"")
+			if errCoord != nil || len(coords) == 2 {
+				return coords, newParseError(ErrCoords, "problem in parsing coordinates for room \" "+roomName+
+					" \"").atToken(i + 1)
 			} else {
 				coords = append(coords, nbr)
 			}
 		}
 	}
 	if len(coords) != 2 {
-		return coords, errors.New("\nERROR: invalid data format, problem in parsing coordinates for room \" " + roomName + " \"")
+		// Missing coordinate, placed after the last token
+		return coords, newParseError(ErrCoords, "problem in parsing coordinates for room \" "+roomName+
+			" \"").atToken(len(roomDetails) + 1)
 	}
 	return coords, nil
 }
@@ -280,14 +456,14 @@ func getRoomCoords(roomDetails []string, roomName string, index int) ([]int, err
 /*
 WriteRoom takes file contents as an input slice of strings, along with a starting index integer where
 room details are expected, as well as the expected class of room (start, end, intermediate). The
-function also writes to the global NetworkMap map variable, initialising the room name key with
-a string slice with capacity equal to the global TotalRoomNbr integer variable.
+function also writes to the NetworkMap of the Farm, initialising the room name key with
+a string slice with capacity equal to the TotalRoomNbr of the Farm.
 */
-func writeRoom(roomName, roomClass string, roomCoords []int) (Room, error) {
+func (farm *Farm) writeRoom(roomName, roomClass string, roomCoords []int) (Room, error) {
 	output := Room{}
 
 	if roomClass != "start" && roomClass != "intermediate" && roomClass != "end" {
-		return output, errors.New("\nERROR: invalid data format, room must either have class " +
+		return output, newParseError(ErrRoomFormat, "room must either have class "+
 			"<start>, <intermediate>, or <end>")
 	}
 	output.Name = roomName
@@ -298,11 +474,11 @@ func writeRoom(roomName, roomClass string, roomCoords []int) (Room, error) {
 
 	// Initialise Links element with maximum capacity so as to
 	// avoid slice appending errors later
-	output.Links = make([]*Room, 0, TotalRoomNbr-1)
+	output.Links = make([]*Room, 0, farm.TotalRoomNbr-1)
 
-	// Add to global NetworkMap variable with an empty slice value with capacity
+	// Add to NetworkMap with an empty slice value with capacity
 	// equal to total number of rooms - 1 (theoretical max number of links per room)
-	errInitialiseMapKey := initialiseMapKey(output.Name)
+	errInitialiseMapKey := farm.initialiseMapKey(output.Name)
 	if errInitialiseMapKey != nil {
 		return output, errInitialiseMapKey
 	}
@@ -315,13 +491,13 @@ its class string ("start", "intermediate" or "end"). It then calls functions Get
 to parse the relevant data, and feeds the outputs to a Room structure which is then returned. If any errors
 are returned by the internal function calls, these are returned.
 */
-func parseRoom(roomLine, roomClass string) (Room, error) {
+func (farm *Farm) parseRoom(roomLine, roomClass string) (Room, error) {
 	output := Room{}
-	roomDetails := strings.Split(roomLine, " ")
+	roomDetails := strings.Fields(roomLine)
 	var roomCoords []int
 
 	if !RegexRoom.MatchString(roomLine) {
-		return output, errors.New("\nERROR: invalid data format, error with entry: " + roomLine)
+		return output, newParseError(ErrRoomFormat, "error with entry: "+roomLine)
 	}
 
 	// Extract room name
@@ -337,7 +513,7 @@ func parseRoom(roomLine, roomClass string) (Room, error) {
 	}
 
 	// Write data to Room struct
-	output, errParse = writeRoom(roomName, roomClass, roomCoords)
+	output, errParse = farm.writeRoom(roomName, roomClass, roomCoords)
 	if errParse != nil {
 		return output, errParse
 	}
@@ -346,16 +522,16 @@ func parseRoom(roomLine, roomClass string) (Room, error) {
 
 /*
 CheckRoomDuplicates takes a Room struct as input and compares its elements for duplicates in
-the global Network variable ([]Room). In the event of a duplicate name or coordinates, a
+the Network ([]Room) of the Farm. In the event of a duplicate name or coordinates, a
 non-nil error is returned.
 */
-func checkRoomDuplicates(inputRoom Room) error {
-	for _, existingRoom := range Network {
+func (farm *Farm) checkRoomDuplicates(inputRoom Room) error {
+	for _, existingRoom := range farm.Network {
 		if reflect.DeepEqual(existingRoom.Name, inputRoom.Name) {
-			return errors.New("\nERROR: invalid data format, duplicate room names detected: " + inputRoom.Name)
+			return newParseError(ErrDuplicateRoom, "duplicate room names detected: "+inputRoom.Name).atToken(1)
 		} else if reflect.DeepEqual(existingRoom.Coords, inputRoom.Coords) {
-			return errors.New("\nERROR: invalid data format, duplicate room coordinates detected for rooms " +
-				existingRoom.Name + " and " + inputRoom.Name)
+			return newParseError(ErrDuplicateCoords, "duplicate room coordinates detected for rooms "+
+				existingRoom.Name+" and "+inputRoom.Name).atToken(2)
 		}
 	}
 	return nil
@@ -363,98 +539,218 @@ func checkRoomDuplicates(inputRoom Room) error {
 
 /*
 ReadRooms reads file contents in the form of an input slice of strings and checks the data for the
-ant colony rooms specified. Properties of the rooms are written to the global Network struct whilst
+ant colony rooms specified. Properties of the rooms are written to the Network of the Farm whilst
 also checking for errors. If an error in the input is found it is returned. Otherwise a nil value
-is returned. Errors
+is returned. A "#capacity N" line sets the Capacity of the (intermediate) room entry which follows it,
+and a "##start N" label (if several start rooms are allowed) the Ants of the start room.
 */
-func readRooms(fileContents []string) error {
+func (farm *Farm) readRooms(fileContents []string) error {
 	startLabel := false
 	endLabel := false
 	roomEntry := Room{}
+	capacity := 0
+	startAnts := 0
 	var errRead error
 	var errDuplicates error
-
-	for _, line := range fileContents {
-		// Check if comment-line or label
-		if RegexComment.MatchString(line) || RegexEmpty.MatchString(line) {
+	farm.Start, farm.End, farm.Starts, farm.Ends = nil, nil, nil, nil
+
+	for i, line := range fileContents {
+		// Check if capacity, comment-line or label
+		if RegexCapacity.MatchString(line) {
+			if capacity > 0 {
+				farm.Network = []Room{} // empty / reset Network
+				return atLine(newParseError(ErrCapacity, "room capacity not followed by a room entry"), i, line)
+			}
+			capacity, errRead = parseCapacity(line)
+			if errRead != nil {
+				farm.Network = []Room{} // empty / reset Network
+				return atLine(errRead, i, line)
+			}
 			continue
-		} else if RegexStart.MatchString(line) && !startLabel {
-			startLabel = true
+		} else if RegexComment.MatchString(line) || RegexEmpty.MatchString(line) {
+			continue
+		} else if class, ants, errLabel := farm.readLabel(line); errLabel != nil {
+			farm.Network = []Room{} // empty / reset Network
+			return atLine(errLabel, i, line)
+		} else if farm.Multi && class != "" && (startLabel || endLabel) {
+			farm.Network = []Room{} // empty / reset Network
+			return atLine(newParseError(ErrMissingRoom, "no room entry discovered after start / end room label"), i, line)
+		} else if class == "start" && !startLabel {
+			startLabel, startAnts = true, ants
 			continue
-		} else if RegexEnd.MatchString(line) && !endLabel {
+		} else if class == "end" && !endLabel {
 			endLabel = true
 			continue
 		}
 
 		// Check if end / start labels active at the same time (no room entry inbetween)
 		if startLabel && endLabel {
-			Network = []Room{} // empty / reset global Network variable
-			return errors.New("\nERROR: invalid data format, no room entries discovered between start and end room labels")
+			farm.Network = []Room{} // empty / reset Network
+			return atLine(newParseError(ErrMissingRoom, "no room entries discovered between start and end room labels"), i, line)
+		} else if capacity > 0 && !RegexRoom.MatchString(line) {
+			farm.Network = []Room{} // empty / reset Network
+			return atLine(newParseError(ErrCapacity, "room capacity not followed by a room entry"), i, line)
 		} else if RegexRoom.MatchString(line) {
-			// Parse and write room data to global Network variable
+			// Parse and write room data to Network
 			if startLabel {
-				roomEntry, errRead = parseRoom(line, "start")
+				roomEntry, errRead = farm.parseRoom(line, "start")
+				roomEntry.Ants = startAnts
 				startLabel = false
 			} else if endLabel {
-				roomEntry, errRead = parseRoom(line, "end")
+				roomEntry, errRead = farm.parseRoom(line, "end")
 				endLabel = false
 			} else if RegexRoom.MatchString(line) {
-				roomEntry, errRead = parseRoom(line, "intermediate")
+				roomEntry, errRead = farm.parseRoom(line, "intermediate")
 			}
 			// Return error if discovered
 			if errRead != nil {
-				Network = []Room{} // empty / reset global Network variable
-				return errRead
+				farm.Network = []Room{} // empty / reset Network
+				return atLine(errRead, i, line)
+			}
+			roomEntry.Capacity, capacity, errRead = applyCapacity(roomEntry, capacity)
+			if errRead != nil {
+				farm.Network = []Room{} // empty / reset Network
+				return atLine(errRead, i, line)
 			}
 			// Check for duplicates
-			errDuplicates = checkRoomDuplicates(roomEntry)
+			errDuplicates = farm.checkRoomDuplicates(roomEntry)
 			if errDuplicates != nil {
-				Network = []Room{} // empty / reset global Network variable
-				return errDuplicates
+				farm.Network = []Room{} // empty / reset Network
+				return atLine(errDuplicates, i, line)
 			}
-			Network = append(Network, roomEntry)
+			farm.Network = append(farm.Network, roomEntry)
 
 			// Write Start / End rooms
-			if roomEntry.Class == "start" {
-				Start = &Network[len(Network)-1]
-			} else if roomEntry.Class == "end" {
-				End = &Network[len(Network)-1]
-			}
+			farm.writeTerminal()
 		}
 	}
 
 	if startLabel || endLabel {
-		Network = []Room{} // empty / reset global Network variable
-		return errors.New("\nERROR: invalid data format, missing start and / or end room data entry")
+		farm.Network = []Room{} // empty / reset Network
+		return newParseError(ErrMissingRoom, "missing start and / or end room data entry")
+	} else if capacity > 0 {
+		farm.Network = []Room{} // empty / reset Network
+		return newParseError(ErrCapacity, "room capacity not followed by a room entry")
+	} else if errStartAnts := farm.checkStartAnts(); errStartAnts != nil {
+		farm.Network = []Room{} // empty / reset Network
+		return errStartAnts
 	}
 	return nil
 }
 
+/*
+isMulti returns true if the input lines contain a "##multi" line, allowing several start and end rooms.
+*/
+func isMulti(fileContents []string) bool {
+	for _, line := range fileContents {
+		if RegexMulti.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+readLabel checks whether the input line is a ##start or ##end label, and returns the class of the room it
+labels ("start" or "end", or "" for any other line), along with the number of ants given for a start room.
+Numbers of ants ("##start N") are only read if several start rooms are allowed (see Multi), as the line is
+otherwise an unknown command. A non-nil error is returned if the number of ants is invalid.
+*/
+func (farm *Farm) readLabel(line string) (string, int, error) {
+	if RegexStart.MatchString(line) {
+		return "start", 0, nil
+	} else if RegexEnd.MatchString(line) {
+		return "end", 0, nil
+	} else if farm.Multi && RegexStartAnt.MatchString(line) {
+		ants, errAnts := parseDirective(line, "start room ant count", ErrStartAnts)
+		return "start", ants, errAnts
+	}
+	return "", 0, nil
+}
+
+/*
+checkStartAnts checks the numbers of ants given for the start rooms of the Farm ("##start N"), which must
+be given either for every start room or for none, and add up to the TotalAntNbr of the Farm. A non-nil
+error is returned otherwise.
+*/
+func (farm *Farm) checkStartAnts() error {
+	given, total := 0, 0
+	for _, start := range farm.Starts {
+		if start.Ants > 0 {
+			given++
+			total += start.Ants
+		}
+	}
+	if given > 0 && given < len(farm.Starts) {
+		return newParseError(ErrStartAnts, "number of ants ( ##start N ) given for some start rooms only, "+
+			"expected all or none")
+	} else if given > 0 && total != farm.TotalAntNbr {
+		return newParseError(ErrStartAnts, "numbers of ants of the start rooms ( ##start N ) add up to "+
+			strconv.Itoa(total)+", expected the number of ants: "+strconv.Itoa(farm.TotalAntNbr))
+	}
+	return nil
+}
+
+/*
+writeTerminal records the room just written to the Network of the Farm among its Starts or Ends, if it
+is a start or end room (the first of each also being written to Start / End).
+*/
+func (farm *Farm) writeTerminal() {
+	room := &farm.Network[len(farm.Network)-1]
+	if room.Class == "start" {
+		farm.Starts = append(farm.Starts, room)
+		if farm.Start == nil {
+			farm.Start = room
+		}
+	} else if room.Class == "end" {
+		farm.Ends = append(farm.Ends, room)
+		if farm.End == nil {
+			farm.End = room
+		}
+	}
+}
+
+/*
+applyCapacity takes a parsed room entry and the capacity given before it (0 if none), and returns the
+Capacity of the room along with the capacity left for the next room entry (always 0). A non-nil error is
+returned if a capacity is given for the start or end room, which can already hold any number of ants.
+*/
+func applyCapacity(roomEntry Room, capacity int) (int, int, error) {
+	if capacity > 0 && roomEntry.Class != "intermediate" {
+		return 0, 0, newParseError(ErrCapacity, "room capacity given for "+roomEntry.Class+" room "+
+			roomEntry.Name+", which can hold any number of ants")
+	}
+	return capacity, 0, nil
+}
+
 /*
 CountRooms takes file contents as an input slice of strings, and counts the number of
 lines which correspond to a room-and-coordinate entry (e.g. RoomA 3 2). It writes this
-count to the global TotalRoomNbr integer variable, whilst also writing over the global
+count to the TotalRoomNbr of the Farm, whilst also writing over its
 Network ([]Room) variable, giving it a max capacity equivalent to the preliminary room
-total (which may be even less depending on duplicates etc.). The global NetworkMap
+total (which may be even less depending on duplicates etc.). The NetworkMap
 variable is also reinitialised to avoid later "assignment to entry in nil map".
 Finally, the function returns an error value, which is not nil in the event of less than
 2 room-coordinate entries being found, or too little / too many start & end room labels
-(##start / ##end).
+(##start / ##end, of which there may be several if the Farm allows it, see Multi).
 */
-func countRooms(fileContents []string) error {
+func (farm *Farm) countRooms(fileContents []string) error {
 	count := 0
 	startLabel := false
 	endLabel := false
 
-	for _, line := range fileContents {
-		if RegexStart.MatchString(line) && !startLabel {
+	for i, line := range fileContents {
+		class, _, _ := farm.readLabel(line)
+		if class == "start" && (!startLabel || farm.Multi) {
 			startLabel = true
-		} else if RegexStart.MatchString(line) && startLabel {
-			return errors.New("\nERROR: invalid data format, multiple start room labels ( ##start ) detected")
-		} else if RegexEnd.MatchString(line) && !endLabel {
+		} else if class == "start" {
+			return atLine(newParseError(ErrMultipleStart, "multiple start room labels ( ##start ) detected "+
+				"(several start rooms require a ##multi line)"), i, line)
+		} else if class == "end" && (!endLabel || farm.Multi) {
 			endLabel = true
-		} else if RegexEnd.MatchString(line) && endLabel {
-			return errors.New("\nERROR: invalid data format, multiple end room labels ( ##end ) detected")
+		} else if class == "end" {
+			return atLine(newParseError(ErrMultipleEnd, "multiple end room labels ( ##end ) detected "+
+				"(several end rooms require a ##multi line)"), i, line)
 		} else if RegexRoom.MatchString(line) {
 			count++
 		}
@@ -462,16 +758,16 @@ func countRooms(fileContents []string) error {
 
 	// Validity checks
 	if !startLabel {
-		return errors.New("\nERROR: invalid data format, no start room label ( ##start ) found")
+		return newParseError(ErrNoStart, "no start room label ( ##start ) found")
 	} else if !endLabel {
-		return errors.New("\nERROR: invalid data format, no end room label ( ##end ) found")
+		return newParseError(ErrNoEnd, "no end room label ( ##end ) found")
 	} else if count < 2 {
 		// Theoretically, at least 2 rooms required ("##start" and "##end")
-		return errors.New("\nERROR: invalid data format, less than 2 valid room entries in input \ngot: " + strconv.Itoa(count))
+		return newParseError(ErrTooFewRooms, "less than 2 valid room entries in input \ngot: "+strconv.Itoa(count))
 	}
-	Network = make([]Room, 0, count)
-	TotalRoomNbr = count
-	NetworkMap = make(map[string][]*Room, TotalRoomNbr)
+	farm.Network = make([]Room, 0, count)
+	farm.TotalRoomNbr = count
+	farm.NetworkMap = make(map[string][]*Room, farm.TotalRoomNbr)
 	return nil
 }
 
@@ -481,11 +777,12 @@ ants specified in the file, whilst also checking for errors in the data input. I
 is found, it is returned. Otherwise the ant total as an integer is returned, along with a
 nil error value.
 */
-func readAnts(fileContents []string) error {
+func (farm *Farm) readAnts(fileContents []string) error {
 	var errReadAnts error
 	foundAnts := false
+	antLine := 0
 
-	for _, line := range fileContents {
+	for i, line := range fileContents {
 		// Check if comment-line
 		if RegexComment.MatchString(line) {
 			continue
@@ -494,137 +791,188 @@ func readAnts(fileContents []string) error {
 		if !foundAnts && RegexAnts.MatchString(line) {
 			// Record ant total if found
 			foundAnts = true
-			TotalAntNbr, errReadAnts = strconv.Atoi(line)
+			antLine = i
+			farm.TotalAntNbr, errReadAnts = strconv.Atoi(line)
 			if errReadAnts != nil {
-				return errors.New("\nERROR: invalid data format, unknown fault in ant number format")
+				return atLine(newParseError(ErrAntCount, "unknown fault in ant number format"), i, line)
 			}
 			continue
 		} else if foundAnts && RegexAnts.MatchString(line) {
 			// Keep scanning input in case multiple ant totals are specified
-			TotalAntNbr = 0
-			return errors.New("\nERROR: invalid data format, multiple ant inputs detected")
+			farm.TotalAntNbr = 0
+			return atLine(newParseError(ErrMultipleAnts, "multiple ant inputs detected"), i, line)
 		}
 	}
-	if TotalAntNbr <= 0 {
-		return errors.New("\nERROR: invalid data format, number of ants must be a positive integer")
-	} else if TotalAntNbr > MaxAnts {
-		return errors.New("\nERROR: invalid data format, maximum number of ants ( " + strconv.Itoa(MaxAnts) + " ) exceeded" +
-			"\nfound: " + strconv.Itoa(TotalAntNbr))
-	} else if !foundAnts {
-		return errors.New("\nERROR: invalid data format, no ant input found")
+	if !foundAnts {
+		return newParseError(ErrNoAnts, "no ant input found")
+	} else if farm.TotalAntNbr <= 0 {
+		return atLine(newParseError(ErrAntCount, "number of ants must be a positive integer"),
+			antLine, fileContents[antLine])
+	} else if farm.TotalAntNbr > MaxAnts {
+		return atLine(newParseError(ErrAntCount, "maximum number of ants ( "+strconv.Itoa(MaxAnts)+" ) exceeded"+
+			"\nfound: "+strconv.Itoa(farm.TotalAntNbr)), antLine, fileContents[antLine])
 	}
 	return nil
 }
 
 /*
-FindDirPath takes a full "folderPath" string and trims the end to the final instance of an input
-root directory "rootDir" string. It thus does not account for sub-directories with the same name
-as the root directory. i.e. if folder path directory is "folder1/folder2/folder2", and the
-root directory is "folder2", the folder path directory will not be trimmed. An error value is
-returned in the event that the root directory is not found.
+readFile takes a "fileName" string (a relative or absolute file path, relative paths being taken from the
+working directory) and writes the contents of the file to a slice of strings, one per line. A non-nil error
+is returned if the file can not be read, or is empty.
 */
-func findDirPath(folderPath string, rootDir string) (string, error) {
-	for i := len(folderPath) - len(rootDir); i >= 0; i-- {
-		if folderPath[i:i+len(rootDir)] == rootDir {
-			folderPath = folderPath[0 : i+len(rootDir)]
-			return folderPath, nil
-		}
+func readFile(fileName string) ([]string, error) {
+	file, errReadFile := os.ReadFile(fileName)
+	if errReadFile != nil {
+		return []string{}, newParseError(ErrFile, "the specified file could not be read / found")
 	}
-	return folderPath, errors.New("\nERROR: invalid data format, the requested root directory " +
-		"could not be found from the input file path")
+	return splitLines(file)
 }
 
 /*
-ReadFile takes a "fileName" string, ans well as root directory ("rootDir") string and writes the
-target file (with "fileName") to a slice of strings. It checks if the input file is an "example" file,
-which in turn is found in "./system/examples/", otherwise the file is assumed to lie within the root
+splitLines takes the raw contents of an input file as a slice of bytes and splits it into a slice of
+strings, one per line, accepting both "\n" and "\r\n" line endings. A non-nil error is returned if
+the input is empty.
 */
-func readFile(fileName string, rootDir string) ([]string, error) {
-	var fileContents []string
-
-	// Establish filepath / root directory
-	// (in case being accessed by program outside of root directory)
-	currentDir, errWD := os.Getwd()
-	if errWD != nil {
-		return fileContents, errWD
-	}
-	rootDir, errRD := findDirPath(currentDir, rootDir)
-	if errRD != nil {
-		return fileContents, errRD
+func splitLines(data []byte) ([]string, error) {
+	if len(data) == 0 {
+		return []string{}, newParseError(ErrFile, "the input file is empty")
 	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.Split(text, "\n"), nil
+}
 
-	// Change filename / path if file is an example / test-file
-	regexExample := regexp.MustCompile(`^((example)|(badexample)|(poorexample))\d*(\.txt)\z`)
-	if regexExample.MatchString(fileName) {
-		fileName = rootDir + "/sys/examples/" + fileName
-	}
+/*
+isValidLine checks that the input line conforms to at least one formatting standard for a valid input,
+ie. is a valid ant number format, or a valid room format, or a valid link format, or a valid comment /
+title line (start / end room), or any other command (" ## " line), which is ignored.
+*/
+func isValidLine(line string) bool {
+	return RegexAnts.MatchString(line) || RegexComment.MatchString(line) ||
+		RegexEmpty.MatchString(line) || RegexRoom.MatchString(line) ||
+		RegexEnd.MatchString(line) || RegexStart.MatchString(line) ||
+		RegexCommand.MatchString(line) || RegexLink.MatchString(line)
+}
 
-	// Write file contents to data structure
-	file, errReadFile := os.ReadFile(fileName)
-	if errReadFile != nil {
-		return fileContents, errors.New("\nERROR: invalid data format, the specified file could not be read / found")
-	} else if len(file) == 0 {
-		return fileContents, errors.New("\nERROR: invalid data format, the input file is empty")
-	}
-	data := strings.ReplaceAll(string(file), "\r\n", "\n")
-	fileContents = strings.Split(data, "\n")
-	return fileContents, nil
+/*
+lineFormatError returns the error for an input line which fails isValidLine.
+*/
+func lineFormatError(line string) *ParseError {
+	return newParseError(ErrLineFormat, "the specified file contains lines "+
+		"with incorrect formatting, eg.: "+line)
 }
 
 /*
-checkValidLines takes an input slice of strings and checks that each line conforms to at least one
-formatting standard for a valid input, ie. is a valid ant number format, or a valid room format, or
-a valid link format, or a valid comment / title line (start / end room). The function returns an
-error value, which is non-nil if the file contains a line which does not confirm to the aforementioned
+checkValidLines takes an input slice of strings and checks each line with isValidLine. The function
+returns an error value, which is non-nil if the file contains a line which does not confirm to the
 formatting guidlines.
 */
 func checkValidLines(fileContents []string) error {
-	for _, line := range fileContents {
-		if !RegexAnts.MatchString(line) && !RegexComment.MatchString(line) &&
-			!RegexEmpty.MatchString(line) && !RegexRoom.MatchString(line) &&
-			!RegexEnd.MatchString(line) && !RegexStart.MatchString(line) &&
-			!RegexLink.MatchString(line) {
-			return errors.New("\nERROR: invalid data format, the specified file contains lines " +
-				"with incorrect formatting, eg.: " + line)
+	for i, line := range fileContents {
+		if !isValidLine(line) {
+			return atLine(lineFormatError(line), i, line)
 		}
 	}
 	return nil
 }
 
 /*
-Setup is a global function which takes a file name as an input and calls the local sys functions
-to process / parse the file and populate the relevant global variables (Network, NetworkMap,
-TotalRoomNbr & TotalAntNbr) to be used by functions in the lem-in/colony package. A non-nil
-error is returned if any errors with the input are found.
+Parse takes file contents as an input slice of strings and calls the local sys functions in their read
+order (ants, room count, rooms, links, line formatting) to populate a new Farm (Network, NetworkMap,
+TotalRoomNbr, TotalAntNbr, Start & End, Starts & Ends). The original lines are retained in the
+FileContents of the Farm for PrintFileContents. The Farm is returned along with the first error
+encountered, if any.
 */
-func Setup(fileName string) error {
-	if !RegexFileName.MatchString(fileName) {
-		return errors.New("\nERROR: invalid data format, input file must be a valid .txt file")
-	}
-
-	fileContents, readFileErr := readFile(fileName, "lem-in")
-	if readFileErr != nil {
-		return readFileErr
-	}
-	readAntsErr := readAnts(fileContents)
+func Parse(fileContents []string) (*Farm, error) {
+	farm := &Farm{FileContents: fileContents, Multi: isMulti(fileContents)}
+	readAntsErr := farm.readAnts(fileContents)
 	if readAntsErr != nil {
-		return readAntsErr
+		return farm, readAntsErr
 	}
-	countRoomsErr := countRooms(fileContents)
+	countRoomsErr := farm.countRooms(fileContents)
 	if countRoomsErr != nil {
-		return countRoomsErr
+		return farm, countRoomsErr
 	}
-	readRoomsErr := readRooms(fileContents)
+	readRoomsErr := farm.readRooms(fileContents)
 	if readRoomsErr != nil {
-		return readRoomsErr
+		return farm, readRoomsErr
 	}
-	readLinksErr := readLinks(fileContents)
+	readLinksErr := farm.readLinks(fileContents)
 	if readLinksErr != nil {
-		return readLinksErr
+		return farm, readLinksErr
 	}
 	generalErr := checkValidLines(fileContents)
 	if generalErr != nil {
-		return generalErr
+		return farm, generalErr
+	}
+	return farm, nil
+}
+
+/*
+readInputFile checks the input file name and reads the named file (see readFile) into a slice of
+strings, one per line. A non-nil error is returned if the file name is invalid or the file cannot be read.
+*/
+func readInputFile(fileName string) ([]string, error) {
+	if !RegexFileName.MatchString(fileName) {
+		return nil, newParseError(ErrFile, "no input file name given")
+	}
+	return readFile(fileName)
+}
+
+/*
+readInput reads the whole input from the io.Reader into a slice of strings, one per line. A non-nil
+error is returned if the input cannot be read or is empty.
+*/
+func readInput(reader io.Reader) ([]string, error) {
+	data, errRead := io.ReadAll(reader)
+	if errRead != nil {
+		return nil, newParseError(ErrFile, "the input could not be read")
+	}
+	return splitLines(data)
+}
+
+/*
+Setup is a global function which takes a file name (path) as an input, reads the file and passes its contents
+to Parse, returning the resulting Farm (Network, NetworkMap, TotalRoomNbr & TotalAntNbr etc.) to be used
+by functions in the lem-in/routing package. A non-nil error is returned if any errors with the input
+are found.
+*/
+func Setup(fileName string) (*Farm, error) {
+	fileContents, readFileErr := readInputFile(fileName)
+	if readFileErr != nil {
+		return nil, readFileErr
+	}
+	return Parse(fileContents)
+}
+
+/*
+SetupFromReader is the equivalent of Setup for input which does not come from a named file (e.g. a farm
+piped to standard input). The whole input is read from the io.Reader and passed to Parse. The resulting
+Farm is returned, along with a non-nil error if the input could not be read, or if any errors with the
+input are found.
+*/
+func SetupFromReader(reader io.Reader) (*Farm, error) {
+	fileContents, errRead := readInput(reader)
+	if errRead != nil {
+		return nil, errRead
+	}
+	return Parse(fileContents)
+}
+
+/*
+PrintFileContents writes the input lines retained in the FileContents of the Farm to the io.Writer,
+exactly as they were read (including ##start / ##end, comments and ignored commands), so that the
+solution can be displayed after the input as the project requires. Trailing blank lines are not
+written. A non-nil error is returned if writing fails.
+*/
+func (farm *Farm) PrintFileContents(writer io.Writer) error {
+	last := len(farm.FileContents)
+	for last > 0 && RegexEmpty.MatchString(farm.FileContents[last-1]) {
+		last--
+	}
+	for _, line := range farm.FileContents[:last] {
+		if _, errWrite := io.WriteString(writer, line+"\n"); errWrite != nil {
+			return errWrite
+		}
 	}
 	return nil
 }
@@ -1,9 +1,12 @@
 package sys
 
 import (
+	"bytes"
 	"errors"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -24,17 +27,18 @@ Check integrity of output "InputInfo" struct:
 */
 
 func TestFindRoomIndex(t *testing.T) {
+	farm := &Farm{}
 	roomIndex := -1
 	var err error
 	e := errors.New("error")
-	Network = []Room{
+	farm.Network = []Room{
 		{Name: "Saturn", Coords: []int{1, 2}, Links: []*Room{}},
 		{Name: "Neptune", Coords: []int{1, 3}, Links: []*Room{}}}
 	testNames := []string{"Saturn", "Neptune", "Pluto", "Nibiru"}
 	correctIndex := []int{0, 1, -1, -1}
 	correctErr := []error{nil, nil, e, e}
 	for i, name := range testNames {
-		roomIndex, err = findRoomIndex(name)
+		roomIndex, err = farm.findRoomIndex(name)
 		if roomIndex != correctIndex[i] {
 			t.Errorf("\nfunction findRoomIndex returning incorrect index"+
 				"\ninput: %v \ngot: %v \nexpected: %v", name, roomIndex, correctIndex[i])
@@ -47,49 +51,53 @@ func TestFindRoomIndex(t *testing.T) {
 }
 
 func TestInitialiseMapKey(t *testing.T) {
-
-	// Set global variables
-	TotalRoomNbr = 3
-	NetworkMap = make(map[string][]*Room, TotalRoomNbr)
-	Network = []Room{
-		{Name: "1", Coords: []int{1, 2}, Links: []*Room{&Network[1]}},
-		{Name: "2", Coords: []int{1, 3}, Links: []*Room{&Network[0]}},
+	farm := &Farm{}
+
+	// Set farm variables
+	farm.TotalRoomNbr = 3
+	farm.NetworkMap = make(map[string][]*Room, farm.TotalRoomNbr)
+	farm.Network = []Room{
+		{Name: "1", Coords: []int{1, 2}, Links: []*Room{}},
+		{Name: "2", Coords: []int{1, 3}, Links: []*Room{}},
 		{Name: "3", Coords: []int{1, 4}, Links: []*Room{}}}
+	farm.Network[0].Links = append(farm.Network[0].Links, &farm.Network[1])
+	farm.Network[1].Links = append(farm.Network[1].Links, &farm.Network[0])
 
-	NetworkMap[Network[0].Name] = append(NetworkMap[Network[0].Name], &Network[1])
-	NetworkMap[Network[1].Name] = append(NetworkMap[Network[0].Name], &Network[0])
+	farm.NetworkMap[farm.Network[0].Name] = append(farm.NetworkMap[farm.Network[0].Name], &farm.Network[1])
+	farm.NetworkMap[farm.Network[1].Name] = append(farm.NetworkMap[farm.Network[0].Name], &farm.Network[0])
 
-	newName := Network[2].Name       // Index 2 of Network has not yet been added to NetworkMap
-	duplicateName := Network[0].Name // Index 0 of Network has not yet been added to NetworkMap
-	errTrue := initialiseMapKey(newName)
-	errFalse := initialiseMapKey(duplicateName)
+	newName := farm.Network[2].Name       // Index 2 of Network has not yet been added to NetworkMap
+	duplicateName := farm.Network[0].Name // Index 0 of Network has not yet been added to NetworkMap
+	errTrue := farm.initialiseMapKey(newName)
+	errFalse := farm.initialiseMapKey(duplicateName)
 
 	if errTrue != nil {
 		t.Errorf("\nfunction initialiseMapKey returning error for non-existent map key"+
 			"\ninput: %v \nerror: %v", newName, errTrue)
-	} else if _, found := NetworkMap[newName]; !found {
+	} else if _, found := farm.NetworkMap[newName]; !found {
 		t.Errorf("\nfunction initialiseMapKey failing to write new valid map key"+
-			"\ninput key: %v \nresulting NetworkMap: %v", newName, NetworkMap)
+			"\ninput key: %v \nresulting NetworkMap: %v", newName, farm.NetworkMap)
 	} else if errFalse == nil {
 		t.Errorf("\nfunction initialiseMapKey not returning error for pre-existing map key"+
-			"\nexisting: %v \ninput key: %v", NetworkMap, duplicateName)
+			"\nexisting: %v \ninput key: %v", farm.NetworkMap, duplicateName)
 	}
 }
 
 func TestWriteLinks(t *testing.T) {
-	// Reset / initialise global variables
-	TotalRoomNbr = 4
-	Network = []Room{
-		{Name: "1", Coords: []int{1, 2}, Links: []*Room{&Network[1]}},
-		{Name: "2", Coords: []int{1, 3}, Links: []*Room{&Network[0]}},
+	farm := &Farm{}
+	// Initialise farm variables
+	farm.TotalRoomNbr = 4
+	farm.Network = []Room{
+		{Name: "1", Coords: []int{1, 2}, Links: []*Room{}},
+		{Name: "2", Coords: []int{1, 3}, Links: []*Room{}},
 		{Name: "3", Coords: []int{1, 4}, Links: []*Room{}},
 		{Name: "4", Coords: []int{1, 5}, Links: []*Room{}}}
-	NetworkMap = make(map[string][]*Room, TotalRoomNbr)
-	// NetworkMap["1"] = []*Room{&Network[1]}
-	// NetworkMap["2"] = []*Room{&Network[0]}
+	farm.Network[0].Links = append(farm.Network[0].Links, &farm.Network[1])
+	farm.Network[1].Links = append(farm.Network[1].Links, &farm.Network[0])
+	farm.NetworkMap = make(map[string][]*Room, farm.TotalRoomNbr)
 
-	for _, room := range Network {
-		errInitialiseMapKey := initialiseMapKey(room.Name)
+	for _, room := range farm.Network {
+		errInitialiseMapKey := farm.initialiseMapKey(room.Name)
 		if errInitialiseMapKey != nil {
 			t.Fatalf("\nfunction unable to initialise map keys for WriteLinks"+
 				"\nerror: %v", errInitialiseMapKey)
@@ -102,11 +110,11 @@ func TestWriteLinks(t *testing.T) {
 	testLinksFalse2 := []string{"5", "1"}
 	testLinksFalse3 := []string{"1", "1"}
 
-	errReadLinksTrue1 := writeLinks(testLinksTrue1)
-	errReadLinksTrue2 := writeLinks(testLinksTrue2)
-	errReadLinksFalse1 := writeLinks(testLinksFalse1)
-	errReadLinksFalse2 := writeLinks(testLinksFalse2)
-	errReadLinksFalse3 := writeLinks(testLinksFalse3)
+	errReadLinksTrue1 := farm.writeLinks(testLinksTrue1, 1)
+	errReadLinksTrue2 := farm.writeLinks(testLinksTrue2, 1)
+	errReadLinksFalse1 := farm.writeLinks(testLinksFalse1, 1)
+	errReadLinksFalse2 := farm.writeLinks(testLinksFalse2, 1)
+	errReadLinksFalse3 := farm.writeLinks(testLinksFalse3, 1)
 
 	// Check valid inputs
 	if errReadLinksTrue1 != nil {
@@ -120,13 +128,13 @@ func TestWriteLinks(t *testing.T) {
 	// Check invalid inputs
 	if errReadLinksFalse1 == nil {
 		t.Errorf("\nfunction writeLinks failing to return an error for invalid inputs (1)"+
-			"\nexisting: %v \ninput: %v", NetworkMap, testLinksFalse1)
+			"\nexisting: %v \ninput: %v", farm.NetworkMap, testLinksFalse1)
 	} else if errReadLinksFalse2 == nil {
 		t.Errorf("\nfunction writeLinks failing to return an error for invalid inputs (2)"+
-			"\nexisting: %v \ninput: %v", NetworkMap, testLinksFalse2)
+			"\nexisting: %v \ninput: %v", farm.NetworkMap, testLinksFalse2)
 	} else if errReadLinksFalse3 == nil {
 		t.Errorf("\nfunction writeLinks failing to return an error for invalid inputs (3)"+
-			"\nexisting: %v \ninput: %v", NetworkMap, testLinksFalse3)
+			"\nexisting: %v \ninput: %v", farm.NetworkMap, testLinksFalse3)
 	}
 }
 
@@ -168,6 +176,7 @@ func TestParseLinks(t *testing.T) {
 }
 
 func TestReadLinks(t *testing.T) {
+	farm := &Farm{}
 	testFiles, errReadDir := os.ReadDir("./examples")
 	if errReadDir != nil {
 		t.Errorf("error in reading from examples directory: %v", errReadDir)
@@ -182,17 +191,18 @@ func TestReadLinks(t *testing.T) {
 
 	// Perform ReadLinks validity checks
 	for i, file := range testFiles {
-		// Reset / empty global variables
-		Network = []Room{}
-		NetworkMap = make(map[string][]*Room)
-
-		fileContents, errReadFile = readFile(file.Name(), "lem-in")
+		fileContents, errReadFile = readFile(filepath.Join("examples", file.Name()))
 		if errReadFile != nil {
 			t.Fatalf("\nerror in reading %s, error returned: \n%s", file.Name(), errReadFile)
 		}
 
-		_ = readRooms(fileContents)
-		readLinksErr = readLinks(fileContents)
+		// Reset / empty farm variables (number of lines as upper bound for number of rooms)
+		farm.Network = []Room{}
+		farm.NetworkMap = make(map[string][]*Room)
+		farm.TotalRoomNbr = len(fileContents)
+
+		_ = farm.readRooms(fileContents)
+		readLinksErr = farm.readLinks(fileContents)
 
 		if readLinksErr != nil && correctErr[i] == nil {
 			t.Errorf("\nfunction readLinks returning unexpected error for test file index: %v"+
@@ -244,27 +254,28 @@ func TestGetRoomCoords(t *testing.T) {
 }
 
 func TestWriteRoom(t *testing.T) {
-	// Reset global variables
-	TotalRoomNbr = 3
-	NetworkMap = make(map[string][]*Room)
+	farm := &Farm{}
+	// Reset farm variables
+	farm.TotalRoomNbr = 3
+	farm.NetworkMap = make(map[string][]*Room)
 
 	roomName1, class1, coords1 := "Big", "start", []int{23, 5}
 	roomName2, class2, coords2 := "Medium", "intermediate", []int{-45, 6}
 	roomName3, class3, coords3 := "Small", "end", []int{5, 10}
 	roomFalse1, classFalse1, coordsFalse1 := "False", "stArt", []int{3, 0}
 
-	room1, err1 := writeRoom(roomName1, class1, coords1)
-	room2, err2 := writeRoom(roomName2, class2, coords2)
-	room3, err3 := writeRoom(roomName3, class3, coords3)
-	_, errFalse := writeRoom(roomFalse1, classFalse1, coordsFalse1)
+	room1, err1 := farm.writeRoom(roomName1, class1, coords1)
+	room2, err2 := farm.writeRoom(roomName2, class2, coords2)
+	room3, err3 := farm.writeRoom(roomName3, class3, coords3)
+	_, errFalse := farm.writeRoom(roomFalse1, classFalse1, coordsFalse1)
 
-	roomTrue1 := Room{Name: roomName1, Class: class1, Coords: coords1, Links: make([]*Room, 0, TotalRoomNbr)}
-	roomTrue2 := Room{Name: roomName2, Class: class2, Coords: coords2, Links: make([]*Room, 0, TotalRoomNbr)}
-	roomTrue3 := Room{Name: roomName3, Class: class3, Coords: coords3, Links: make([]*Room, 0, TotalRoomNbr)}
-	_, found1 := NetworkMap[roomName1]
-	_, found2 := NetworkMap[roomName2]
-	_, found3 := NetworkMap[roomName3]
-	_, found := NetworkMap[roomFalse1]
+	roomTrue1 := Room{Name: roomName1, Class: class1, Coords: coords1, Links: make([]*Room, 0, farm.TotalRoomNbr)}
+	roomTrue2 := Room{Name: roomName2, Class: class2, Coords: coords2, Links: make([]*Room, 0, farm.TotalRoomNbr)}
+	roomTrue3 := Room{Name: roomName3, Class: class3, Coords: coords3, Links: make([]*Room, 0, farm.TotalRoomNbr)}
+	_, found1 := farm.NetworkMap[roomName1]
+	_, found2 := farm.NetworkMap[roomName2]
+	_, found3 := farm.NetworkMap[roomName3]
+	_, found := farm.NetworkMap[roomFalse1]
 
 	// Check valid inputs
 	if !reflect.DeepEqual(room1, roomTrue1) {
@@ -277,8 +288,8 @@ func TestWriteRoom(t *testing.T) {
 		t.Errorf("\nfunction writeRoom not returning correct room struct (3)"+
 			"\ngot: %v, error: %v \nexpecting: %v", room3, err3, roomTrue3)
 	} else if !found1 || !found2 || !found3 || found {
-		t.Errorf("\nfunction writeRoom not resulting in filling global NetworkMap variable"+
-			"\ngot: %v", NetworkMap)
+		t.Errorf("\nfunction writeRoom not resulting in filling NetworkMap"+
+			"\ngot: %v", farm.NetworkMap)
 	}
 
 	// Check invalid input
@@ -289,23 +300,25 @@ func TestWriteRoom(t *testing.T) {
 }
 
 func TestParseRoom(t *testing.T) {
-	TotalRoomNbr = 3
+	farm := &Farm{}
+	farm.TotalRoomNbr = 3
+	farm.NetworkMap = make(map[string][]*Room)
 	correctRoom1, correctRoom2, correctRoom3 := "vgh 23 0", "   BigRoom -55 100", " SmallRoom   10     10000"
 	correctClass1, correctClass2, correctClass3 := "start", "intermediate", "end"
 	falseRoom1, falseRoom2 := "g 0 100000000000000000000", "   BigRoom 20 100"
 	falseClass := "End"
 	expectedRoom1 := Room{Name: "vgh", Class: "start", Coords: []int{23, 0},
-		Links: make([]*Room, 0, TotalRoomNbr)}
+		Links: make([]*Room, 0, farm.TotalRoomNbr)}
 	expectedRoom2 := Room{Name: "BigRoom", Class: "intermediate", Coords: []int{-55, 100},
-		Links: make([]*Room, 0, TotalRoomNbr)}
+		Links: make([]*Room, 0, farm.TotalRoomNbr)}
 	expectedRoom3 := Room{Name: "SmallRoom", Class: "end", Coords: []int{10, 10000},
-		Links: make([]*Room, 0, TotalRoomNbr)}
+		Links: make([]*Room, 0, farm.TotalRoomNbr)}
 
-	corrRoom1, err1 := parseRoom(correctRoom1, correctClass1)
-	corrRoom2, err2 := parseRoom(correctRoom2, correctClass2)
-	corrRoom3, err3 := parseRoom(correctRoom3, correctClass3)
-	_, errFalse1 := parseRoom(falseRoom1, correctClass1)
-	_, errFalse2 := parseRoom(falseRoom2, falseClass)
+	corrRoom1, err1 := farm.parseRoom(correctRoom1, correctClass1)
+	corrRoom2, err2 := farm.parseRoom(correctRoom2, correctClass2)
+	corrRoom3, err3 := farm.parseRoom(correctRoom3, correctClass3)
+	_, errFalse1 := farm.parseRoom(falseRoom1, correctClass1)
+	_, errFalse2 := farm.parseRoom(falseRoom2, falseClass)
 
 	// Check return of correct inputs
 	if !reflect.DeepEqual(corrRoom1, expectedRoom1) || err1 != nil {
@@ -333,7 +346,8 @@ func TestParseRoom(t *testing.T) {
 }
 
 func TestCheckRoomDuplicates(t *testing.T) {
-	Network = []Room{
+	farm := &Farm{}
+	farm.Network = []Room{
 		{Name: "Joe", Coords: []int{1, 2}},
 		{Name: "Bob", Coords: []int{1, 3}}}
 	uniqueRoom := Room{Name: "Barry", Coords: []int{1, 4}}
@@ -341,13 +355,13 @@ func TestCheckRoomDuplicates(t *testing.T) {
 	doubleRoomCoords := Room{Name: "Frank", Coords: []int{1, 2}}
 
 	// Perform validity checks
-	trueErr := checkRoomDuplicates(uniqueRoom)
-	doubleNameErr := checkRoomDuplicates(doubleRoomName)
-	doubleCoordsErr := checkRoomDuplicates(doubleRoomCoords)
+	trueErr := farm.checkRoomDuplicates(uniqueRoom)
+	doubleNameErr := farm.checkRoomDuplicates(doubleRoomName)
+	doubleCoordsErr := farm.checkRoomDuplicates(doubleRoomCoords)
 
 	if trueErr != nil {
 		t.Errorf("\nfunction checkRoomDuplicates returning error with valid input"+
-			"\nexisting: %v \ninput: %v \n got: %v", Network, uniqueRoom, trueErr)
+			"\nexisting: %v \ninput: %v \n got: %v", farm.Network, uniqueRoom, trueErr)
 	} else if doubleNameErr == nil {
 		t.Errorf("\nfunction checkRoomDuplicates not detecting duplicate room names")
 	} else if doubleCoordsErr == nil {
@@ -356,6 +370,7 @@ func TestCheckRoomDuplicates(t *testing.T) {
 }
 
 func TestReadRooms(t *testing.T) {
+	farm := &Farm{}
 	testFiles, errReadDir := os.ReadDir("./examples")
 	if errReadDir != nil {
 		t.Errorf("error in reading from examples directory: %v", errReadDir)
@@ -371,30 +386,165 @@ func TestReadRooms(t *testing.T) {
 
 	// Perform ReadRooms validity checks
 	for i, file := range testFiles {
-		// Reset / empty global variables
-		Network = []Room{}
-		NetworkMap = make(map[string][]*Room)
-
-		fileContents, errReadFile = readFile(file.Name(), "lem-in")
+		fileContents, errReadFile = readFile(filepath.Join("examples", file.Name()))
 		if errReadFile != nil {
 			t.Fatalf("\nerror in reading %s, error returned: \n%s", file.Name(), errReadFile)
 		}
 
-		readRoomsErr = readRooms(fileContents)
+		// Reset / empty farm variables (number of lines as upper bound for number of rooms)
+		farm.Network = []Room{}
+		farm.NetworkMap = make(map[string][]*Room)
+		farm.TotalRoomNbr = len(fileContents)
+
+		readRoomsErr = farm.readRooms(fileContents)
 
 		if readRoomsErr != nil && correctErr[i] == nil {
 			t.Errorf("\nfunction readRooms returning unexpected error for test file index: %v"+
 				"\ngot: %v", i, readRoomsErr)
 		} else if readRoomsErr == nil && correctErr[i] != nil {
 			t.Errorf("\nfunction readRooms not returning expected error for test file index: %v", i)
-		} else if len(Network) != correctNetworkLength[i] {
+		} else if len(farm.Network) != correctNetworkLength[i] {
 			t.Errorf("\nfunction readRooms not populating Network with expected number of rooms for test file index: %v"+
-				"\n# rooms populated: %v \n# rooms expected: %v", i, len(Network), correctNetworkLength[i])
+				"\n# rooms populated: %v \n# rooms expected: %v", i, len(farm.Network), correctNetworkLength[i])
+		}
+	}
+}
+
+func TestRoomCapacity(t *testing.T) {
+	// Valid capacities, with a comment between the capacity and its room
+	valid := []string{"3", "#capacity 3", "a 1 1", "##start", "s 0 0", "#capacity 2", "# chamber", "b 2 2",
+		"c 3 3", "##end", "e 4 4", "s-a", "a-b", "b-c", "c-e"}
+	farm, err := Parse(valid)
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error for valid room capacities"+
+			"\ngot: %v", err)
+	}
+	correctCapacity := map[string]int{"a": 3, "s": 1, "b": 2, "c": 1, "e": 1}
+	for _, room := range farm.Network {
+		if room.AntCapacity() != correctCapacity[room.Name] {
+			t.Errorf("\nfunction Parse not writing expected capacity for room %v"+
+				"\ngot: %v \nexpected: %v", room.Name, room.AntCapacity(), correctCapacity[room.Name])
+		}
+	}
+	if !farm.HasCapacities() {
+		t.Errorf("\nfunction HasCapacities not detecting rooms with a capacity")
+	}
+
+	// Invalid capacities, with the expected line number
+	invalid := [][]string{
+		{"3", "#capacity 0", "a 1 1", "##start", "s 0 0", "##end", "e 4 4", "s-a", "a-e"},   // not positive
+		{"3", "#capacity x", "a 1 1", "##start", "s 0 0", "##end", "e 4 4", "s-a", "a-e"},   // not an integer
+		{"3", "#capacity 2 3", "a 1 1", "##start", "s 0 0", "##end", "e 4 4", "s-a", "a-e"}, // two values
+		{"3", "a 1 1", "#capacity 2", "##start", "s 0 0", "##end", "e 4 4", "s-a", "a-e"},   // start room
+		{"3", "a 1 1", "##start", "s 0 0", "##end", "e 4 4", "#capacity 2", "s-a", "a-e"},   // no room
+		{"3", "a 1 1", "##start", "s 0 0", "##end", "e 4 4", "s-a", "a-e", "#capacity 2"},   // end of file
+	}
+	correctLine := []int{2, 2, 2, 5, 7, 9}
+	for i, fileContents := range invalid {
+		_, err = Parse(fileContents)
+		if !errors.Is(err, ErrCapacity) {
+			t.Errorf("\nfunction Parse not returning expected error for invalid room capacity (%v)"+
+				"\ngot: %v", i, err)
+		}
+		errs := Lint(fileContents)
+		if len(errs) != 1 || errs[0].Kind != ErrCapacity || errs[0].Line != correctLine[i] {
+			t.Errorf("\nfunction Lint not returning expected error for invalid room capacity (%v)"+
+				"\ngot: %v \nexpected line: %v", i, errs, correctLine[i])
+		}
+	}
+}
+
+func TestTunnelWeight(t *testing.T) {
+	// Valid weights, with a comment between the weight and its link
+	valid := []string{"3", "##start", "s 0 0", "a 1 1", "b 2 2", "##end", "e 3 3", "#weight 3", "s-a",
+		"#weight 2", "# slow tunnel", "a-e", "s-b", "b-e"}
+	farm, err := Parse(valid)
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error for valid tunnel weights"+
+			"\ngot: %v", err)
+	}
+	correctWeight := map[[2]string]int{{"s", "a"}: 3, {"a", "s"}: 3, {"a", "e"}: 2, {"e", "a"}: 2,
+		{"s", "b"}: 1, {"b", "s"}: 1, {"b", "e"}: 1, {"e", "b"}: 1}
+	for _, room := range farm.Network {
+		for _, link := range room.Links {
+			if room.Weight(link) != correctWeight[[2]string{room.Name, link.Name}] {
+				t.Errorf("\nfunction Parse not writing expected weight for tunnel %v-%v"+
+					"\ngot: %v \nexpected: %v", room.Name, link.Name, room.Weight(link),
+					correctWeight[[2]string{room.Name, link.Name}])
+			}
+		}
+	}
+	if !farm.HasWeights() {
+		t.Errorf("\nfunction HasWeights not detecting weighted tunnels")
+	}
+
+	// Invalid weights, with the expected line number
+	invalid := [][]string{
+		{"3", "##start", "s 0 0", "a 1 1", "##end", "e 3 3", "#weight 0", "s-a", "a-e"},              // not positive
+		{"3", "##start", "s 0 0", "a 1 1", "##end", "e 3 3", "#weight x", "s-a", "a-e"},              // not an integer
+		{"3", "##start", "s 0 0", "#weight 2", "a 1 1", "##end", "e 3 3", "s-a", "a-e"},              // no link
+		{"3", "##start", "s 0 0", "a 1 1", "##end", "e 3 3", "s-a", "a-e", "#weight 2"},              // end of file
+		{"3", "##start", "s 0 0", "a 1 1", "##end", "e 3 3", "#weight 2", "#weight 2", "s-a", "a-e"}, // twice
+	}
+	correctLine := []int{7, 7, 4, 9, 7}
+	for i, fileContents := range invalid {
+		_, err = Parse(fileContents)
+		if !errors.Is(err, ErrWeight) {
+			t.Errorf("\nfunction Parse not returning expected error for invalid tunnel weight (%v)"+
+				"\ngot: %v", i, err)
+		}
+		errs := Lint(fileContents)
+		if len(errs) != 1 || errs[0].Kind != ErrWeight || errs[0].Line != correctLine[i] {
+			t.Errorf("\nfunction Lint not returning expected error for invalid tunnel weight (%v)"+
+				"\ngot: %v \nexpected line: %v", i, errs, correctLine[i])
+		}
+	}
+}
+
+func TestMultipleTerminals(t *testing.T) {
+	// Two start rooms with their numbers of ants, and two end rooms
+	valid := []string{"5", "##multi", "##start 3", "s1 0 0", "##start 2", "s2 0 1", "a 1 0", "##end", "e1 2 0",
+		"##end", "e2 2 1", "s1-a", "s2-a", "a-e1", "a-e2"}
+	farm, err := Parse(valid)
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error for several start and end rooms"+
+			"\ngot: %v", err)
+	}
+	if len(farm.Starts) != 2 || len(farm.Ends) != 2 || farm.Start != farm.Starts[0] || farm.End != farm.Ends[0] ||
+		farm.Starts[0].Name != "s1" || farm.Starts[1].Name != "s2" || farm.Ends[1].Name != "e2" {
+		t.Errorf("\nfunction Parse not writing expected start and end rooms"+
+			"\ngot starts: %v \nends: %v", farm.Starts, farm.Ends)
+	} else if farm.Starts[0].Ants != 3 || farm.Starts[1].Ants != 2 || !farm.HasStartAnts() {
+		t.Errorf("\nfunction Parse not writing expected numbers of ants of the start rooms"+
+			"\ngot: %v, %v", farm.Starts[0].Ants, farm.Starts[1].Ants)
+	}
+
+	// Invalid start and end rooms, with the expected line number (0 if not tied to a line)
+	invalid := [][]string{
+		{"5", "##start", "s1 0 0", "##start", "s2 0 1", "##end", "e 2 0", "s1-e", "s2-e"},                // no ##multi
+		{"5", "##multi", "##start 3", "s1 0 0", "##start", "s2 0 1", "##end", "e 2 0", "s1-e", "s2-e"},   // some counts
+		{"5", "##multi", "##start 3", "s1 0 0", "##start 3", "s2 0 1", "##end", "e 2 0", "s1-e", "s2-e"}, // wrong sum
+		{"5", "##multi", "##start x", "s1 0 0", "##start", "s2 0 1", "##end", "e 2 0", "s1-e", "s2-e"},   // not a count
+		{"5", "##multi", "##start", "##start", "s2 0 1", "##end", "e 2 0", "s2-e"},                       // no room
+	}
+	correctKind := []ErrorKind{ErrMultipleStart, ErrStartAnts, ErrStartAnts, ErrStartAnts, ErrMissingRoom}
+	correctLine := []int{4, 0, 0, 3, 4}
+	for i, fileContents := range invalid {
+		_, err = Parse(fileContents)
+		if !errors.Is(err, correctKind[i]) {
+			t.Errorf("\nfunction Parse not returning expected error for invalid start / end rooms (%v)"+
+				"\ngot: %v \nexpected: %v", i, err, correctKind[i])
+		}
+		errs := Lint(fileContents)
+		if len(errs) != 1 || errs[0].Kind != correctKind[i] || errs[0].Line != correctLine[i] {
+			t.Errorf("\nfunction Lint not returning expected error for invalid start / end rooms (%v)"+
+				"\ngot: %v \nexpected line: %v", i, errs, correctLine[i])
 		}
 	}
 }
 
 func TestCountRooms(t *testing.T) {
+	farm := &Farm{}
 	testFiles, errReadDir := os.ReadDir("./examples")
 	if errReadDir != nil {
 		t.Errorf("error in reading from examples directory: %v", errReadDir)
@@ -410,21 +560,21 @@ func TestCountRooms(t *testing.T) {
 
 	// Perform CountRooms validity checks on example files
 	for i, file := range testFiles {
-		// Reset global variables
-		Network = make([]Room, 0)
-		TotalRoomNbr = 0
+		// Reset farm variables
+		farm.Network = make([]Room, 0)
+		farm.TotalRoomNbr = 0
 
-		fileContents, errReadFile = readFile(file.Name(), "lem-in")
+		fileContents, errReadFile = readFile(filepath.Join("examples", file.Name()))
 		if errReadFile != nil {
 			t.Fatalf("\nerror in reading %s, error returned: \n%s", file.Name(), errReadFile)
 		}
-		errCountRooms = countRooms(fileContents)
-		if TotalRoomNbr != correctTotalRoomNbr[i] {
+		errCountRooms = farm.countRooms(fileContents)
+		if farm.TotalRoomNbr != correctTotalRoomNbr[i] {
 			t.Errorf("\nfunction countRooms not returning correct room count"+
-				"\ngot: %v, expected, %v", TotalRoomNbr, correctTotalRoomNbr[i])
-		} else if TotalRoomNbr != cap(Network) {
-			t.Errorf("\nfunction countRooms not populating global Network variable with the correct room capacity"+
-				"\nNumber of Rooms: %v, Capacity of Network, %v", TotalRoomNbr, cap(Network))
+				"\ngot: %v, expected, %v", farm.TotalRoomNbr, correctTotalRoomNbr[i])
+		} else if farm.TotalRoomNbr != cap(farm.Network) {
+			t.Errorf("\nfunction countRooms not populating Network with the correct room capacity"+
+				"\nNumber of Rooms: %v, Capacity of Network, %v", farm.TotalRoomNbr, cap(farm.Network))
 		} else if errCountRooms != nil && correctErr[i] == nil {
 			t.Errorf("\nfunction countRooms producing unexpected error:"+
 				"\ngot: %v", errCountRooms)
@@ -436,6 +586,7 @@ func TestCountRooms(t *testing.T) {
 }
 
 func TestReadAnts(t *testing.T) {
+	farm := &Farm{}
 	testFiles, errReadDir := os.ReadDir("./examples")
 	if errReadDir != nil {
 		t.Errorf("error in reading from examples directory: %v", errReadDir)
@@ -452,15 +603,15 @@ func TestReadAnts(t *testing.T) {
 
 	// Perform ReadAnt validity checks on example files
 	for i, file := range testFiles {
-		TotalAntNbr = 0
-		fileContents, errReadFile = readFile(file.Name(), "lem-in")
+		farm.TotalAntNbr = 0
+		fileContents, errReadFile = readFile(filepath.Join("examples", file.Name()))
 		if errReadFile != nil {
 			t.Fatalf("\nerror in reading %s, error returned: \n%s", file.Name(), errReadFile)
 		}
-		errReadAnts = readAnts(fileContents)
-		if TotalAntNbr != correctAnts[i] {
+		errReadAnts = farm.readAnts(fileContents)
+		if farm.TotalAntNbr != correctAnts[i] {
 			t.Errorf("\nfunction readAnts not reading correct ant number"+
-				"\ngot: %v, expected, %v", TotalAntNbr, correctAnts[i])
+				"\ngot: %v, expected, %v", farm.TotalAntNbr, correctAnts[i])
 		} else if errReadAnts != nil && correctErr[i] == nil {
 			t.Errorf("\nfunction readAnts producing unexpected error:"+
 				"\ngot: %v", errReadAnts)
@@ -471,23 +622,6 @@ func TestReadAnts(t *testing.T) {
 	}
 }
 
-func TestFindDirPath(t *testing.T) {
-	testDirPath1, testRootDir1 := "Users/test1/test2", "test1"
-	testDirPath2, testRootDir2 := "Users/test1/test2", "test3"
-	testDirPath3, testRootDir3 := "Users/test1/test2", "loooooooooooooonnnnggggteeeeeestttttt"
-	newPath1, err1 := findDirPath(testDirPath1, testRootDir1)
-	_, err2 := findDirPath(testDirPath2, testRootDir2)
-	_, err3 := findDirPath(testDirPath3, testRootDir3)
-	if newPath1 != "Users/test1" || err1 != nil {
-		t.Errorf("\nfunction findDirPath failing on trimming to valid root directory")
-	} else if err2 == nil {
-		t.Errorf("\nfunction findDirPath failing to detect non valid root directory path (not present)")
-	} else if err3 == nil {
-		t.Errorf("\nfunction findDirPath failing to detect non valid root directory path " +
-			"(root directory length exceeds directory path length)")
-	}
-}
-
 func TestReadFile(t *testing.T) {
 	testFiles, errReadDir := os.ReadDir("./examples")
 	if errReadDir != nil {
@@ -499,7 +633,7 @@ func TestReadFile(t *testing.T) {
 	correctLengths := []int{16, 40, 10, 34, 11, 16, 17, 66, 17, 17, 41, 39, 38, 24}
 	var errReadFile error
 	for i, file := range testFiles {
-		testSlice[i], errReadFile = readFile(file.Name(), "lem-in")
+		testSlice[i], errReadFile = readFile(filepath.Join("examples", file.Name()))
 		if errReadFile != nil {
 			t.Fatalf("\nerror in reading %s, error returned: \n%s", file.Name(), errReadFile)
 		} else if len(testSlice[i]) != correctLengths[i] {
@@ -523,8 +657,8 @@ func TestReadFile(t *testing.T) {
 func TestCheckValidLines(t *testing.T) {
 	e := errors.New("ERROR")
 	var err error
-	lines := [][]string{{"goodName 1 2"}, {"good_Name 1 2"}, {"1-2"}, {"1=2"}}
-	correct := []error{nil, e, nil, e}
+	lines := [][]string{{"goodName 1 2"}, {"good_Name 1 2"}, {"1-2"}, {"1=2"}, {"##unknown"}}
+	correct := []error{nil, e, nil, e, nil}
 
 	// Perform checks
 	for i, line := range lines {
@@ -547,10 +681,54 @@ func TestSetup(t *testing.T) {
 	correctErr := []error{e, e, nil, nil, nil, nil, nil, nil, nil, nil, e, e, e, e}
 
 	for i, file := range testFiles {
-		errSetup := Setup(file.Name())
+		_, errSetup := Setup(filepath.Join("examples", file.Name()))
 		if (errSetup != nil && correctErr[i] == nil) ||
 			(errSetup == nil && correctErr[i] != nil) {
 			t.Errorf("\nfunction Setup not working as expected for file: %s", file.Name())
 		}
 	}
 }
+
+func TestSetupFromReader(t *testing.T) {
+	testFiles, errReadDir := os.ReadDir("./examples")
+	if errReadDir != nil {
+		t.Errorf("error in reading from examples directory: %v", errReadDir)
+	}
+
+	e := errors.New("error")
+	correctErr := []error{e, e, nil, nil, nil, nil, nil, nil, nil, nil, e, e, e, e}
+
+	for i, file := range testFiles {
+		data, errReadFile := os.ReadFile("./examples/" + file.Name())
+		if errReadFile != nil {
+			t.Fatalf("\nerror in reading %s, error returned: \n%s", file.Name(), errReadFile)
+		}
+		_, errSetup := SetupFromReader(strings.NewReader(string(data)))
+		if (errSetup != nil && correctErr[i] == nil) ||
+			(errSetup == nil && correctErr[i] != nil) {
+			t.Errorf("\nfunction SetupFromReader not working as expected for file: %s", file.Name())
+		}
+	}
+
+	// Empty input
+	_, errEmpty := SetupFromReader(strings.NewReader(""))
+	if errEmpty == nil {
+		t.Errorf("\nfunction SetupFromReader not detecting empty input")
+	}
+}
+
+func TestPrintFileContents(t *testing.T) {
+	farm := &Farm{}
+	farm.FileContents = []string{"3", "##start", "#comment", "a 0 0", "##unknown", "##end", "b 1 1", "a-b", "", ""}
+	correctOutput := "3\n##start\n#comment\na 0 0\n##unknown\n##end\nb 1 1\na-b\n"
+
+	var output bytes.Buffer
+	errPrint := farm.PrintFileContents(&output)
+	if errPrint != nil {
+		t.Errorf("\nfunction PrintFileContents returning unexpected error"+
+			"\ngot: %v", errPrint)
+	} else if output.String() != correctOutput {
+		t.Errorf("\nfunction PrintFileContents not reproducing input lines"+
+			"\ngot: %q \nexpected: %q", output.String(), correctOutput)
+	}
+}
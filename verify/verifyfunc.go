@@ -0,0 +1,381 @@
+package verify
+
+import (
+	"errors"
+	"io"
+	"lem-in/sys"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+Violation describes a single illegal move (or other problem) found in a move log, along with the
+1-based line number of the move log and the 1-based turn number in which it occurred. A Line / Turn
+value of 0 signifies a problem that is not tied to a specific line (e.g. ants never finishing).
+*/
+type Violation struct {
+	Line    int
+	Turn    int
+	Message string
+}
+
+/*
+Report is the result of replaying a move log against the Network of a sys.Farm: the number of
+turns found in the log, along with all violations found in the order they were encountered.
+*/
+type Report struct {
+	Turns      int
+	Violations []Violation
+}
+
+var RegexMove = regexp.MustCompile(`^L(\d+)-(\S+)\z`)
+
+/*
+String formats a Violation as a single diagnostic line, prefixed with its line and turn numbers.
+*/
+func (violation Violation) String() string {
+	if violation.Line == 0 {
+		return violation.Message
+	}
+	return "line " + strconv.Itoa(violation.Line) + ", turn " + strconv.Itoa(violation.Turn) +
+		": " + violation.Message
+}
+
+/*
+ReadLog takes a file name string and returns the contents of the move log as a slice of strings (one
+per line), accepting both "\n" and "\r\n" line endings. If the file name is "-", the log is read from
+standard input. A non-nil error is returned if the log could not be read.
+*/
+func ReadLog(fileName string) ([]string, error) {
+	var data []byte
+	var errRead error
+	if fileName == "-" {
+		data, errRead = io.ReadAll(os.Stdin)
+	} else {
+		data, errRead = os.ReadFile(fileName)
+	}
+	if errRead != nil {
+		return []string{}, errors.New("\nERROR: the specified move log could not be read / found")
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.Split(text, "\n"), nil
+}
+
+/*
+isMoveLine returns true if the line consists of move tokens only, ie. every field of the line is a single
+move " Lx-y " (see RegexMove). Room names may start with the letter "L" (e.g. " La 0 0 "), so the lines of
+an echoed farm are not told apart by their first letter.
+*/
+func isMoveLine(line string) bool {
+	fields := strings.Fields(line)
+	for _, field := range fields {
+		if !RegexMove.MatchString(field) {
+			return false
+		}
+	}
+	return len(fields) > 0
+}
+
+/*
+firstMoveLine returns the index of the line of the move log from which the moves are read. If the move log
+starts with the echoed farm (any line other than a blank line or a move line, see isMoveLine), the farm is
+skipped up to (and including) the first blank line following it, as the links of rooms named " L<number> "
+look like moves (e.g. " L1-a "). Otherwise, the moves are read from the first line.
+*/
+func firstMoveLine(moveLog []string) int {
+	for i, line := range moveLog {
+		if isMoveLine(line) {
+			return 0
+		} else if !sys.RegexEmpty.MatchString(line) {
+			for i < len(moveLog) && !sys.RegexEmpty.MatchString(moveLog[i]) {
+				i++
+			}
+			if i < len(moveLog) {
+				i++ // Blank line separating the farm from the moves
+			}
+			return i
+		}
+	}
+	return len(moveLog)
+}
+
+/*
+Check replays a move log (as a slice of lines, each line being one turn of "Lx-y" tokens) against the Network,
+start and end rooms and TotalAntNbr of the input sys.Farm, as returned by sys.Setup (see initialPositions for
+farms with several start rooms). Any echoed farm preceding the moves (see firstMoveLine) and blank lines are
+skipped (see turnLines). Every illegal move is recorded in the returned Report: malformed tokens, ant IDs
+outside of 1..TotalAntNbr, unknown rooms, moves between rooms which are not linked, ants moving twice in a
+turn or after reaching the end room, ants reaching a room sooner than the weight of the tunnel allows (see
+sys.Room.Weight), more ants in an intermediate room at the end of a turn than it can hold (one, unless given a
+capacity, see sys.Room.AntCapacity), and ants which never reach the end room. A non-nil error is returned if
+the farm has not been set up.
+*/
+func Check(farm *sys.Farm, moveLog []string) (Report, error) {
+	return CheckRules(farm, moveLog, sys.RulesRooms)
+}
+
+/*
+move is a legal (or at least applied) move of an ant, from the room it was in to the room it reached in the
+turn of its move log line, having left its room in the "departure" turn (earlier than the turn of the line,
+if the tunnel is weighted).
+*/
+type move struct {
+	ant       int
+	from      *sys.Room
+	to        *sys.Room
+	departure int
+}
+
+/*
+turnLines returns the indices of the lines of the move log holding one turn each: every line from the first
+move line on (see firstMoveLine), except blank lines. On a farm with weighted tunnels (see sys.Farm.HasWeights),
+turns in which no ant reaches a room are printed as blank lines, so that blank lines between move lines are
+turns as well, as are the blank lines preceding the first move line (after the blank line separating the moves
+from any echoed farm). Blank lines following the last move line are never turns.
+*/
+func turnLines(moveLog []string, weighted bool) []int {
+	start := firstMoveLine(moveLog)
+	first, last := -1, -1
+	for i := start; i < len(moveLog); i++ {
+		if !sys.RegexEmpty.MatchString(moveLog[i]) {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return nil
+	} else if weighted {
+		first = start
+	}
+
+	var lines []int
+	for i := first; i <= last; i++ {
+		if weighted || !sys.RegexEmpty.MatchString(moveLog[i]) {
+			lines = append(lines, i)
+		}
+	}
+	return lines
+}
+
+/*
+CheckRules is the equivalent of Check for the rule set named by rules: sys.RulesRooms (the rules checked
+by Check), or sys.RulesTunnels, where intermediate rooms may hold any number of ants but every tunnel may
+only be used by a single ant (in either direction) per turn. The move log is read in two passes: the moves
+of every line are checked one by one first, and then replayed turn by turn, with ants leaving their room
+(and entering the tunnel) in the turn of their departure, and reaching the next room in the turn of their
+move log line. Ants in transit through a weighted tunnel are not in any room. Violations are reported in
+the order of their lines. A non-nil error is returned if the farm has not been set up, or if the rule set
+is unknown.
+*/
+func CheckRules(farm *sys.Farm, moveLog []string, rules string) (Report, error) {
+	report := Report{}
+	if farm.Start == nil || farm.End == nil {
+		return report, errors.New("\nERROR: internal malfunction, the function \" CheckRules \" " +
+			"called while the Start and/or End rooms of the Farm are empty")
+	} else if rules != sys.RulesRooms && rules != sys.RulesTunnels {
+		return report, errors.New("\nERROR: internal malfunction, unknown rule set \" " + rules + " \" " +
+			"\nexpected \" " + sys.RulesRooms + " \" or \" " + sys.RulesTunnels + " \"")
+	}
+	roomsByName := make(map[string]*sys.Room, len(farm.Network))
+	for i := range farm.Network {
+		roomsByName[farm.Network[i].Name] = &farm.Network[i]
+	}
+
+	lines := turnLines(moveLog, farm.HasWeights())
+	report.Turns = len(lines)
+	addViolation := func(turn int, message string) {
+		report.Violations = append(report.Violations, Violation{Line: lines[turn-1] + 1, Turn: turn, Message: message})
+	}
+
+	// First pass: check the moves of every line, and find the turn in which each ant left its room
+	position := make([]*sys.Room, farm.TotalAntNbr+1) // Index 0 unused, ant IDs start at 1
+	arrival := make([]int, farm.TotalAntNbr+1)        // Turn in which each ant reached its position
+	initialPositions(farm, position)
+	departing := make([][]move, len(lines)+1) // Moves by turn of departure
+	arriving := make([][]move, len(lines)+1)  // Moves by turn of arrival
+	usedStarts := make(map[startTunnel]bool)  // Tunnels from start rooms used, under sys.RulesTunnels
+	finished := 0
+
+	for i, lineIndex := range lines {
+		turn := i + 1
+		movedThisTurn := make(map[int]bool)
+		for _, token := range strings.Fields(moveLog[lineIndex]) {
+			match := RegexMove.FindStringSubmatch(token)
+			if match == nil {
+				addViolation(turn, "malformed move \" "+token+" \", expected \" Lx-y \"")
+				continue
+			}
+			ant, errAtoi := strconv.Atoi(match[1])
+			if errAtoi != nil || ant < 1 || ant > farm.TotalAntNbr {
+				addViolation(turn, "ant ID in \" "+token+" \" outside of range 1.."+strconv.Itoa(farm.TotalAntNbr))
+				continue
+			}
+			target, found := roomsByName[match[2]]
+			if !found {
+				addViolation(turn, "ant L"+match[1]+" moved to unknown room \" "+match[2]+" \"")
+				continue
+			}
+			if movedThisTurn[ant] {
+				addViolation(turn, "ant L"+match[1]+" moved more than once in the same turn")
+				continue
+			}
+			movedThisTurn[ant] = true
+			current := position[ant]
+			if current == nil {
+				current = leaveStart(farm, target, turn, usedStarts)
+			}
+			if current.Class == "end" {
+				addViolation(turn, "ant L"+match[1]+" moved after already reaching the end room")
+				continue
+			}
+			departure := turn
+			if !isLinked(current, target) {
+				addViolation(turn, "ant L"+match[1]+" moved from \" "+current.Name+" \" to \" "+
+					target.Name+" \", which are not linked")
+			} else if departure = turn - current.Weight(target) + 1; departure <= arrival[ant] {
+				addViolation(turn, "ant L"+match[1]+" reached \" "+target.Name+" \" from \" "+current.Name+
+					" \" in less than the "+strconv.Itoa(current.Weight(target))+" turns taken by the tunnel")
+				departure = arrival[ant] + 1
+			}
+
+			if rules == sys.RulesTunnels && current.Class == "start" {
+				usedStarts[startTunnel{current, target, departure}] = true
+			}
+
+			// Apply move (even if illegal, to avoid cascading diagnostics)
+			antMove := move{ant: ant, from: current, to: target, departure: departure}
+			departing[departure] = append(departing[departure], antMove)
+			arriving[turn] = append(arriving[turn], antMove)
+			position[ant], arrival[ant] = target, turn
+			if target.Class == "end" {
+				finished++
+			}
+		}
+	}
+
+	// Second pass: replay the moves turn by turn, checking the tunnels used and the occupancy of rooms
+	occupants := make(map[*sys.Room]int, len(farm.Network))
+	for turn := 1; turn <= len(lines); turn++ {
+		usedTunnels := make(map[[2]*sys.Room]bool)
+		for _, antMove := range departing[turn] {
+			current, target := antMove.from, antMove.to
+			if rules == sys.RulesTunnels && isLinked(current, target) && (usedTunnels[[2]*sys.Room{current, target}] ||
+				usedTunnels[[2]*sys.Room{target, current}]) {
+				addViolation(turn, "ant L"+strconv.Itoa(antMove.ant)+" moved through the tunnel between \" "+
+					current.Name+" \" and \" "+target.Name+" \", which was already used in the same turn")
+			}
+			usedTunnels[[2]*sys.Room{current, target}] = true
+			if current.Class == "intermediate" {
+				occupants[current]--
+			}
+		}
+		var entered []*sys.Room
+		for _, antMove := range arriving[turn] {
+			if antMove.to.Class == "intermediate" {
+				occupants[antMove.to]++
+				entered = append(entered, antMove.to)
+			}
+		}
+
+		// Check occupancy of intermediate rooms at the end of the turn
+		if rules == sys.RulesTunnels {
+			continue
+		}
+		reported := make(map[*sys.Room]bool)
+		for _, room := range entered {
+			if occupants[room] > room.AntCapacity() && !reported[room] {
+				reported[room] = true
+				addViolation(turn, "room \" "+room.Name+" \" holds "+strconv.Itoa(occupants[room])+
+					" ants at the end of the turn")
+			}
+		}
+	}
+	sort.SliceStable(report.Violations, func(a, b int) bool {
+		return report.Violations[a].Line < report.Violations[b].Line
+	})
+
+	if finished < farm.TotalAntNbr {
+		for ant := 1; ant <= farm.TotalAntNbr; ant++ {
+			if position[ant] == nil {
+				report.Violations = append(report.Violations, Violation{Message: "ant L" + strconv.Itoa(ant) +
+					" never reached the end room (never left the start rooms)"})
+			} else if position[ant].Class != "end" {
+				report.Violations = append(report.Violations, Violation{Message: "ant L" + strconv.Itoa(ant) +
+					" never reached the end room (last seen in \" " + position[ant].Name + " \")"})
+			}
+		}
+	}
+	return report, nil
+}
+
+/*
+initialPositions writes the start room of every ant (by ant ID) to the input slice of positions. Farms with
+a single start room have all ants start there, and if the number of ants of each start room is given (see
+sys.Farm.HasStartAnts), ants are numbered by start room: the ants of the first start room first, then those
+of the second start room, and so on, in the order of the Starts of the Farm. Otherwise, the ants of a farm
+with several start rooms may start in any of them, and their position is left nil until their first move
+(see leaveStart).
+*/
+func initialPositions(farm *sys.Farm, position []*sys.Room) {
+	if farm.HasStartAnts() {
+		ant := 1
+		for _, start := range farm.Starts {
+			for i := 0; i < start.Ants && ant < len(position); i++ {
+				position[ant] = start
+				ant++
+			}
+		}
+	} else if len(farm.Starts) <= 1 {
+		for ant := 1; ant < len(position); ant++ {
+			position[ant] = farm.Start
+		}
+	}
+}
+
+/*
+startTunnel is the tunnel from a start room to the target room of a move, along with the turn of its departure.
+*/
+type startTunnel struct {
+	start     *sys.Room
+	target    *sys.Room
+	departure int
+}
+
+/*
+leaveStart returns the start room left by an ant moving to the target room on its first move (in the given
+turn), where ants may start in any of several start rooms (see initialPositions): the first start room of the
+Farm linked to the target room from which the ant could have reached it by that turn (see sys.Room.Weight),
+through a tunnel which is not found among the used tunnels. Failing that, the first start room linked to the
+target room is returned, or the first start room of the Farm if none is (so that the move is reported as such).
+*/
+func leaveStart(farm *sys.Farm, target *sys.Room, turn int, used map[startTunnel]bool) *sys.Room {
+	for _, start := range farm.Starts {
+		departure := turn - start.Weight(target) + 1
+		if isLinked(start, target) && departure >= 1 && !used[startTunnel{start, target, departure}] {
+			return start
+		}
+	}
+	for _, start := range farm.Starts {
+		if isLinked(start, target) {
+			return start
+		}
+	}
+	return farm.Start
+}
+
+/*
+isLinked returns true if the room "to" is found in the Links of room "from".
+*/
+func isLinked(from, to *sys.Room) bool {
+	for _, link := range from.Links {
+		if link == to {
+			return true
+		}
+	}
+	return false
+}
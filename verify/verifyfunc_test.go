@@ -0,0 +1,225 @@
+package verify
+
+import (
+	"lem-in/sys"
+	"strings"
+	"testing"
+)
+
+/*
+setupTestFarm returns a small farm: start - 1 - 2 - end, plus start - 3 - end.
+*/
+func setupTestFarm() *sys.Farm {
+	farm := &sys.Farm{}
+	farm.TotalAntNbr = 3
+	farm.Network = []sys.Room{{Name: "start", Class: "start"}, {Name: "1", Class: "intermediate"},
+		{Name: "2", Class: "intermediate"}, {Name: "3", Class: "intermediate"}, {Name: "end", Class: "end"}}
+	links := [][2]int{{0, 1}, {1, 2}, {2, 4}, {0, 3}, {3, 4}}
+	for _, link := range links {
+		farm.Network[link[0]].Links = append(farm.Network[link[0]].Links, &farm.Network[link[1]])
+		farm.Network[link[1]].Links = append(farm.Network[link[1]].Links, &farm.Network[link[0]])
+	}
+	farm.Start, farm.End = &farm.Network[0], &farm.Network[4]
+	return farm
+}
+
+func TestCheck(t *testing.T) {
+	farm := setupTestFarm()
+
+	// Valid move log, preceded by an echoed farm
+	validLog := []string{"3", "##start", "start 0 0", "start-1", "", "L1-1 L2-3", "L1-2 L2-end L3-3", "L1-end L3-end", ""}
+	report, err := Check(farm, validLog)
+	if err != nil || report.Turns != 3 || len(report.Violations) != 0 {
+		t.Errorf("\nfunction Check not accepting valid move log"+
+			"\ngot turns: %v \nviolations: %v \nerror: %v", report.Turns, report.Violations, err)
+	}
+
+	// Invalid move logs, with the expected line / turn numbers of the first violation
+	invalidLogs := [][]string{
+		{"L1-2", "L1-end", "L2-3", "L2-end", "L3-3", "L3-end"},               // rooms not linked
+		{"L1-1 L2-1", "L1-2", "L1-end L2-2", "L2-end L3-3", "L3-end"},        // two ants in one room
+		{"L1-3 L1-end", "L2-3", "L2-end", "L3-3", "L3-end"},                  // ant moves twice in a turn
+		{"L1-3", "L1-end", "L2-3", "L2-end"},                                 // ant never finishes
+		{"L1-3", "L1-end", "L2-3", "L2-end", "L4-3 L3-3", "L3-end"},          // ant ID out of range
+		{"L1-3", "L1-end", "L1-3 L2-3", "L2-end", "L3-3", "L3-end"},          // ant moves after finishing
+		{"L1-3", "L1-end", "L2-3", "L2-end", "L3-3", "L3-nowhere", "L3-end"}, // unknown room
+		{"L1-3", "L1-end", "L2-3", "L2-end", "L3-3", "L3end", "L3-end"},      // malformed move
+	}
+	correctLine := []int{1, 1, 1, 0, 5, 3, 6, 6}
+	correctTurn := []int{1, 1, 1, 0, 5, 3, 6, 6}
+
+	for i, moveLog := range invalidLogs {
+		report, err = Check(farm, moveLog)
+		if err != nil || len(report.Violations) == 0 {
+			t.Errorf("\nfunction Check not detecting invalid move log (%v)"+
+				"\ninput: %v \nerror: %v", i, moveLog, err)
+		} else if report.Violations[0].Line != correctLine[i] || report.Violations[0].Turn != correctTurn[i] {
+			t.Errorf("\nfunction Check not reporting correct line / turn for invalid move log (%v)"+
+				"\ngot: %v \nexpected line: %v, turn: %v", i, report.Violations[0], correctLine[i], correctTurn[i])
+		}
+	}
+}
+
+func TestCheckLRooms(t *testing.T) {
+	// Farm with rooms starting with the letter "L": start - La - L1 - end
+	echoedFarm := []string{"2", "##start", "start 0 0", "La 1 0", "L1 2 0", "##end", "end 3 0", "start-La", "La-L1",
+		"L1-end", ""}
+	farm, err := sys.Parse(echoedFarm[:len(echoedFarm)-1])
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error"+
+			"\ngot: %v", err)
+	}
+
+	moves := []string{"L1-La", "L1-L1 L2-La", "L1-end L2-L1", "L2-end", ""}
+	for _, moveLog := range [][]string{moves, append(echoedFarm, moves...)} {
+		report, err := Check(farm, moveLog)
+		if err != nil || report.Turns != 4 || len(report.Violations) != 0 {
+			t.Errorf("\nfunction Check not accepting valid move log on farm with rooms starting with \" L \""+
+				"\ngot turns: %v \nviolations: %v \nerror: %v", report.Turns, report.Violations, err)
+		}
+	}
+}
+
+func TestCheckCapacity(t *testing.T) {
+	farm := setupTestFarm()
+	farm.Network[1].Capacity = 2
+
+	// Two ants may wait in room 1, but not three
+	validLog := []string{"L1-1", "L2-1", "L1-2", "L1-end L2-2", "L2-end L3-3", "L3-end"}
+	report, err := Check(farm, validLog)
+	if err != nil || len(report.Violations) != 0 {
+		t.Errorf("\nfunction Check not accepting valid move log for room with a capacity"+
+			"\ngot violations: %v \nerror: %v", report.Violations, err)
+	}
+	invalidLog := []string{"L1-1", "L2-1", "L3-1", "L1-2", "L1-end L2-2", "L2-end L3-2", "L3-end"}
+	report, err = Check(farm, invalidLog)
+	if err != nil || len(report.Violations) != 1 || report.Violations[0].Turn != 3 {
+		t.Errorf("\nfunction Check not detecting more ants in a room than its capacity"+
+			"\ngot violations: %v \nerror: %v", report.Violations, err)
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	farm := setupTestFarm()
+
+	// Two ants waiting in room 1 is only legal under the tunnel rules
+	sharedRoomLog := []string{"L1-1", "L2-1", "L1-2 L3-3", "L1-end L2-2 L3-end", "L2-end"}
+	report, err := CheckRules(farm, sharedRoomLog, sys.RulesTunnels)
+	if err != nil || len(report.Violations) != 0 {
+		t.Errorf("\nfunction CheckRules not accepting valid move log under tunnel rules"+
+			"\ngot violations: %v \nerror: %v", report.Violations, err)
+	}
+	report, err = CheckRules(farm, sharedRoomLog, sys.RulesRooms)
+	if err != nil || len(report.Violations) == 0 || report.Violations[0].Turn != 2 {
+		t.Errorf("\nfunction CheckRules not detecting two ants in one room under room rules"+
+			"\ngot violations: %v \nerror: %v", report.Violations, err)
+	}
+
+	// Two ants through the same tunnel in one turn, in the same or in opposite directions
+	invalidLogs := [][]string{
+		{"L1-1 L2-1", "L1-2", "L1-end L2-2", "L2-end L3-3", "L3-end"},
+		{"L1-1", "L1-2 L2-1", "L1-1 L2-2", "L1-2 L2-end", "L1-end L3-3", "L3-end"},
+	}
+	correctTurn := []int{1, 3}
+	for i, moveLog := range invalidLogs {
+		report, err = CheckRules(farm, moveLog, sys.RulesTunnels)
+		if err != nil || len(report.Violations) != 1 || report.Violations[0].Turn != correctTurn[i] {
+			t.Errorf("\nfunction CheckRules not detecting shared tunnel under tunnel rules (%v)"+
+				"\ngot violations: %v \nerror: %v", i, report.Violations, err)
+		}
+	}
+
+	// Unknown rule set
+	_, err = CheckRules(farm, sharedRoomLog, "doors")
+	if err == nil {
+		t.Errorf("\nfunction CheckRules not returning error for unknown rule set")
+	}
+}
+
+func TestCheckWeights(t *testing.T) {
+	farm := setupTestFarm()
+	farm.Network[1].Weights = map[*sys.Room]int{&farm.Network[2]: 2}
+	farm.Network[2].Weights = map[*sys.Room]int{&farm.Network[1]: 2}
+
+	// Ant 1 leaves room 1 in turn 2 and reaches room 2 in turn 3, so that ant 2 may enter room 1 in turn 2
+	validLogs := [][]string{
+		{"L1-1", "L2-1 L3-3", "L1-2 L3-end", "L1-end L2-2", "L2-end"},
+		{"3", "##start", "start 0 0", "", "L1-1", "", "L1-2", "L1-end", "L2-3", "L2-end L3-3", "L3-end", ""},
+	}
+	correctTurns := []int{5, 7}
+	for i, moveLog := range validLogs {
+		report, err := Check(farm, moveLog)
+		if err != nil || report.Turns != correctTurns[i] || len(report.Violations) != 0 {
+			t.Errorf("\nfunction Check not accepting valid move log for weighted tunnel (%v)"+
+				"\ngot turns: %v \nviolations: %v \nerror: %v", i, report.Turns, report.Violations, err)
+		}
+	}
+
+	// A move log without the echoed farm, starting with empty turns (ants crossing the weighted tunnel)
+	farm, err := sys.Parse([]string{"2", "##start", "s 0 0", "a 1 0", "##end", "e 2 0", "#weight 3", "s-a", "a-e"})
+	if err != nil {
+		t.Fatalf("\nfunction Parse returning unexpected error"+
+			"\ngot: %v", err)
+	}
+	bareLogs := [][]string{
+		{"", "", "L1-a", "L1-e L2-a", "L2-e", ""},
+		{"2", "##start", "s 0 0", "", "", "", "L1-a", "L1-e L2-a", "L2-e"},
+	}
+	for i, moveLog := range bareLogs {
+		report, err := Check(farm, moveLog)
+		if err != nil || report.Turns != 5 || len(report.Violations) != 0 {
+			t.Errorf("\nfunction Check not counting leading empty turns of weighted move log (%v)"+
+				"\ngot turns: %v \nviolations: %v \nerror: %v", i, report.Turns, report.Violations, err)
+		}
+	}
+
+	// Ant 1 reaching room 2 one turn after reaching room 1
+	farm = setupTestFarm()
+	farm.Network[1].Weights = map[*sys.Room]int{&farm.Network[2]: 2}
+	farm.Network[2].Weights = map[*sys.Room]int{&farm.Network[1]: 2}
+	invalidLog := []string{"L1-1 L2-3", "L1-2 L2-end L3-3", "L1-end L3-end"}
+	report, err := Check(farm, invalidLog)
+	if err != nil || len(report.Violations) != 1 || report.Violations[0].Line != 2 {
+		t.Errorf("\nfunction Check not detecting ant crossing weighted tunnel too soon"+
+			"\ngot violations: %v \nerror: %v", report.Violations, err)
+	}
+}
+
+func TestCheckMultiple(t *testing.T) {
+	// Start rooms s1 (4 ants) and s2 (2 ants), end rooms e1 and e2
+	fileContents := []string{"6", "##multi", "##start 4", "s1 0 0", "##start 2", "s2 0 4", "a 2 0", "b 2 4",
+		"c 2 2", "##end", "e1 4 0", "##end", "e2 4 4", "s1-a", "a-e1", "s2-b", "b-e2", "s1-c", "c-e2", "s2-c"}
+	farm, err := sys.Parse(fileContents)
+	if err != nil {
+		t.Fatalf("\nerror in parsing test farm \ngot: %v", err)
+	}
+	validLog := []string{"L1-a L2-c L5-b", "L1-e1 L2-e2 L5-e2 L3-a L4-c L6-b", "L3-e1 L4-e2 L6-e2"}
+	report, err := CheckRules(farm, validLog, sys.RulesTunnels)
+	if err != nil || report.Turns != 3 || len(report.Violations) != 0 {
+		t.Errorf("\nfunction CheckRules not accepting valid move log for several start and end rooms"+
+			"\ngot turns: %v \nviolations: %v \nerror: %v", report.Turns, report.Violations, err)
+	}
+
+	// Ant 5 starting in s2, which is not linked to room a
+	invalidLog := []string{"L1-c L5-a", "L1-e2 L5-e1"}
+	report, err = Check(farm, invalidLog)
+	if err != nil || len(report.Violations) == 0 || report.Violations[0].Line != 1 ||
+		!strings.Contains(report.Violations[0].Message, "s2") {
+		t.Errorf("\nfunction Check not detecting ant leaving the wrong start room"+
+			"\ngot violations: %v \nerror: %v", report.Violations, err)
+	}
+
+	// Without numbers of ants, ants may leave either start room: ants 1 and 2 both reach room c in the first
+	// turn, through the tunnels from s1 and from s2
+	fileContents[2], fileContents[4] = "##start", "##start"
+	farm, err = sys.Parse(fileContents)
+	if err != nil {
+		t.Fatalf("\nerror in parsing test farm \ngot: %v", err)
+	}
+	validLog = []string{"L1-c L2-c L3-a L4-b", "L1-e2 L3-e1 L4-e2 L5-a L6-b", "L2-e2 L5-e1 L6-e2"}
+	report, err = CheckRules(farm, validLog, sys.RulesTunnels)
+	if err != nil || len(report.Violations) != 0 {
+		t.Errorf("\nfunction CheckRules not accepting valid move log for shared start rooms"+
+			"\ngot violations: %v \nerror: %v", report.Violations, err)
+	}
+}
@@ -0,0 +1,250 @@
+package visual
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"lem-in/routing"
+	"lem-in/sys"
+	"strconv"
+	"strings"
+)
+
+/*
+routeColours is the palette used to tell the routes of a solution apart (repeated for more routes).
+*/
+var routeColours = []string{"#e6194b", "#3cb44b", "#4363d8", "#f58231", "#911eb4", "#42d4f4",
+	"#f032e6", "#9a6324", "#469990", "#800000"}
+
+const (
+	svgMargin    = 40.0
+	svgMaxWidth  = 800.0
+	svgMaxHeight = 600.0
+	svgMaxUnit   = 80.0 // Maximum number of pixels per coordinate unit, for small farms
+	svgLineStep  = 18.0 // Height of a line of the route legend
+)
+
+/*
+svgLayout maps the Coords of the rooms of a sys.Farm to pixel positions within the SVG image, keyed by
+room name, and returns them along with the width and height of the drawing area.
+*/
+func svgLayout(farm *sys.Farm) (map[string][2]float64, float64, float64) {
+	positions := make(map[string][2]float64, len(farm.Network))
+	if len(farm.Network) == 0 {
+		return positions, 2 * svgMargin, 2 * svgMargin
+	}
+
+	minX, maxX := farm.Network[0].Coords[0], farm.Network[0].Coords[0]
+	minY, maxY := farm.Network[0].Coords[1], farm.Network[0].Coords[1]
+	for _, room := range farm.Network {
+		minX, maxX = minInt(minX, room.Coords[0]), maxInt(maxX, room.Coords[0])
+		minY, maxY = minInt(minY, room.Coords[1]), maxInt(maxY, room.Coords[1])
+	}
+	unit := svgMaxUnit
+	if maxX > minX && svgMaxWidth/float64(maxX-minX) < unit {
+		unit = svgMaxWidth / float64(maxX-minX)
+	}
+	if maxY > minY && svgMaxHeight/float64(maxY-minY) < unit {
+		unit = svgMaxHeight / float64(maxY-minY)
+	}
+
+	for _, room := range farm.Network {
+		positions[room.Name] = [2]float64{svgMargin + unit*float64(room.Coords[0]-minX),
+			svgMargin + unit*float64(room.Coords[1]-minY)}
+	}
+	return positions, 2*svgMargin + unit*float64(maxX-minX), 2*svgMargin + unit*float64(maxY-minY)
+}
+
+/*
+formatFloat formats a pixel value, without trailing zeros.
+*/
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+/*
+svgLine returns an SVG line element between two positions, with the given colour and width.
+*/
+func svgLine(from, to [2]float64, colour string, width int) string {
+	return `<line x1="` + formatFloat(from[0]) + `" y1="` + formatFloat(from[1]) + `" x2="` + formatFloat(to[0]) +
+		`" y2="` + formatFloat(to[1]) + `" stroke="` + colour + `" stroke-width="` + strconv.Itoa(width) +
+		`" stroke-linecap="round"/>` + "\n"
+}
+
+/*
+renderSVG draws the Farm of the Solver as an SVG image: all tunnels in grey, the Routes of the Solver
+drawn over them (each in a different colour), the rooms as circles at their coordinates with their names
+(start room in green and end room in red, labelled as such), and a legend listing each route with its
+length and number of ants (from the InitialGrouping of the Solver). Any extra SVG elements (e.g. the
+ants of the HTML animation) are added before the end of the image.
+*/
+func renderSVG(solver *routing.Solver, extra string) (string, error) {
+	if solver.Farm == nil || len(solver.Routes) == 0 || len(solver.InitialGrouping) != len(solver.Routes) {
+		return "", errors.New("\nERROR: internal malfunction, the farm can only be exported " +
+			"once a solution has been found by the Solver")
+	}
+	positions, width, height := svgLayout(solver.Farm)
+	legendHeight := svgLineStep * float64(len(solver.Routes)+1)
+	width = maxFloat(width+2*svgMargin, 300) // Room for the labels of the rightmost rooms
+
+	var svg strings.Builder
+	svg.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" width="` + formatFloat(width) + `" height="` +
+		formatFloat(height+legendHeight) + `" font-family="monospace" font-size="12">` + "\n")
+	svg.WriteString(`<rect width="100%" height="100%" fill="white"/>` + "\n")
+
+	// Tunnels
+	roomIndex := make(map[*sys.Room]int, len(solver.Farm.Network))
+	for i := range solver.Farm.Network {
+		roomIndex[&solver.Farm.Network[i]] = i
+	}
+	for i, room := range solver.Farm.Network {
+		for _, link := range room.Links {
+			if j, found := roomIndex[link]; found && j > i {
+				svg.WriteString(svgLine(positions[room.Name], positions[link.Name], "#bbbbbb", 2))
+			}
+		}
+	}
+
+	// Routes, followed by the legend
+	for i, route := range solver.Routes {
+		colour := routeColours[i%len(routeColours)]
+		for j := 0; j < len(route)-1; j++ {
+			svg.WriteString(svgLine(positions[route[j].Name], positions[route[j+1].Name], colour, 4))
+		}
+	}
+	for i, route := range solver.Routes {
+		y := height + svgLineStep*float64(i+1)
+		svg.WriteString(`<text x="` + formatFloat(svgMargin/2) + `" y="` + formatFloat(y) + `" fill="` +
+			routeColours[i%len(routeColours)] + `">route ` + strconv.Itoa(i+1) + ` (` + strconv.Itoa(len(route)-1) +
+			` moves): ` + strconv.Itoa(solver.InitialGrouping[i]) + ` ants</text>` + "\n")
+	}
+
+	// Rooms
+	for _, room := range solver.Farm.Network {
+		position := positions[room.Name]
+		fill, label := "white", html.EscapeString(room.Name)
+		if room.Class == "start" {
+			fill, label = "#3cb44b", label+" (start)"
+		} else if room.Class == "end" {
+			fill, label = "#e6194b", label+" (end)"
+		}
+		svg.WriteString(`<circle cx="` + formatFloat(position[0]) + `" cy="` + formatFloat(position[1]) +
+			`" r="8" fill="` + fill + `" stroke="black"/>` + "\n")
+		svg.WriteString(`<text x="` + formatFloat(position[0]+10) + `" y="` + formatFloat(position[1]-10) +
+			`">` + label + `</text>` + "\n")
+	}
+
+	svg.WriteString(extra)
+	svg.WriteString("</svg>\n")
+	return svg.String(), nil
+}
+
+/*
+WriteSVG writes a static SVG rendering of the farm and the routes chosen by the Solver (see renderSVG)
+to the io.Writer. Run must have been called on the Solver. A non-nil error is returned if the Solver
+has no solution, or if writing fails.
+*/
+func WriteSVG(writer io.Writer, solver *routing.Solver) error {
+	svg, err := renderSVG(solver, "")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(writer, svg)
+	return err
+}
+
+/*
+htmlScript animates the ants of the exported HTML page. The room positions and turns are inserted as
+JSON in place of ROOMS, TURNS, ANTS and STARTS (the start room of every ant, see antStarts).
+*/
+const htmlScript = `<script>
+const rooms = ROOMS, turns = TURNS, totalAnts = ANTS, starts = STARTS;
+const layer = document.getElementById("ants");
+let turn = 0, timer = null;
+function positions(t) {
+  const at = {};
+  for (let ant = 1; ant <= totalAnts; ant++) at[ant] = starts[ant];
+  for (let i = 0; i < t; i++) for (const move of turns[i]) at[move.ant] = move.to;
+  return at;
+}
+function draw() {
+  const at = positions(turn);
+  layer.innerHTML = "";
+  const counts = {};
+  for (let ant = 1; ant <= totalAnts; ant++) {
+    const room = at[ant];
+    counts[room] = (counts[room] || 0) + 1;
+    if (room === starts[ant] || counts[room] > 1) continue;
+    const c = document.createElementNS("http://www.w3.org/2000/svg", "circle");
+    c.setAttribute("cx", rooms[room][0]);
+    c.setAttribute("cy", rooms[room][1]);
+    c.setAttribute("r", 5);
+    c.setAttribute("fill", "black");
+    const title = document.createElementNS("http://www.w3.org/2000/svg", "title");
+    title.textContent = "L" + ant;
+    c.appendChild(title);
+    layer.appendChild(c);
+  }
+  document.getElementById("turn").textContent = "turn " + turn + " / " + turns.length +
+    (turn > 0 ? "  " + turns[turn - 1].map(m => "L" + m.ant + "-" + m.to).join(" ") : "");
+}
+function step(n) { turn = Math.max(0, Math.min(turns.length, turn + n)); draw(); }
+function play() {
+  if (timer) { clearInterval(timer); timer = null; return; }
+  timer = setInterval(() => { if (turn >= turns.length) { clearInterval(timer); timer = null; } else step(1); }, 500);
+}
+function rewind() { turn = 0; draw(); }
+draw();
+</script>
+`
+
+/*
+WriteHTML writes a self-contained HTML page to the io.Writer, containing the SVG rendering of the farm
+and routes (see renderSVG) along with an embedded animation of the ants, turn by turn, with play / pause,
+step and rewind buttons. Run must have been called on the Solver with RecordTurns set to true. A non-nil
+error is returned if the Solver has no solution, or if writing fails.
+*/
+func WriteHTML(writer io.Writer, solver *routing.Solver) error {
+	svg, err := renderSVG(solver, `<g id="ants"></g>`+"\n")
+	if err != nil {
+		return err
+	}
+	positions, _, _ := svgLayout(solver.Farm)
+	roomsJSON, err := json.Marshal(positions)
+	if err != nil {
+		return err
+	}
+	turns := solver.Turns
+	if turns == nil {
+		turns = [][]routing.Move{}
+	}
+	turnsJSON, err := json.Marshal(turns)
+	if err != nil {
+		return err
+	}
+	startsJSON, err := json.Marshal(antStarts(solver.Farm, turns))
+	if err != nil {
+		return err
+	}
+
+	script := strings.NewReplacer("ROOMS", string(roomsJSON), "TURNS", string(turnsJSON),
+		"ANTS", strconv.Itoa(solver.Farm.TotalAntNbr), "STARTS", string(startsJSON)).Replace(htmlScript)
+	page := "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>lem-in</title>\n</head>\n" +
+		"<body style=\"font-family: monospace\">\n<div>\n" +
+		"<button onclick=\"rewind()\">rewind</button>\n<button onclick=\"step(-1)\">back</button>\n" +
+		"<button onclick=\"play()\">play / pause</button>\n<button onclick=\"step(1)\">next</button>\n" +
+		"<span id=\"turn\"></span>\n</div>\n" + svg + script + "</body>\n</html>\n"
+	_, err = io.WriteString(writer, page)
+	return err
+}
+
+/*
+maxFloat returns the larger of two float64 values.
+*/
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
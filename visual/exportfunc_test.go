@@ -0,0 +1,68 @@
+package visual
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"lem-in/routing"
+	"lem-in/sys"
+	"strings"
+	"testing"
+)
+
+func TestWriteSVG(t *testing.T) {
+	// Establish test variables: two routes (S-1-E and S-2-3-E) and a dead end (4)
+	farm, err := sys.Parse([]string{"5", "##start", "S 0 0", "1 1 0", "2 0 1", "3 1 1", "4 2 2", "##end",
+		"E 2 0", "S-1", "1-E", "S-2", "2-3", "3-E", "3-4"})
+	if err != nil {
+		t.Fatalf("\nerror in parsing test farm \ngot: %v", err)
+	}
+	solver := routing.NewSolver(farm)
+	solver.EchoInput = false
+	solver.Output = &bytes.Buffer{}
+	solver.RecordTurns = true
+
+	var output bytes.Buffer
+	if err = WriteSVG(&output, solver); err == nil {
+		t.Errorf("\nfunction WriteSVG not returning error before the Solver has been run")
+	}
+	if err = solver.Run(context.Background()); err != nil {
+		t.Fatalf("\nSolver returning unexpected error for test farm \ngot: %v", err)
+	}
+
+	output.Reset()
+	if err = WriteSVG(&output, solver); err != nil {
+		t.Fatalf("\nfunction WriteSVG returning unexpected error \ngot: %v", err)
+	}
+	svg := output.String()
+	if errXML := xml.Unmarshal([]byte(svg), new(interface{})); errXML != nil {
+		t.Errorf("\nfunction WriteSVG not writing well-formed XML \nerror: %v", errXML)
+	}
+	correct := map[string]int{
+		"<circle":                    6, // One per room
+		`stroke="#bbbbbb"`:           6, // One per tunnel
+		`stroke="` + routeColours[0]: 2, // Route S-1-E
+		`stroke="` + routeColours[1]: 3, // Route S-2-3-E
+		"S (start)":                  1,
+		"E (end)":                    1,
+		"route 1 (2 moves): 3 ants":  1,
+		"route 2 (3 moves): 2 ants":  1,
+	}
+	for element, count := range correct {
+		if strings.Count(svg, element) != count {
+			t.Errorf("\nfunction WriteSVG not writing the expected number of \" %v \""+
+				"\ngot: %v \nexpected: %v", element, strings.Count(svg, element), count)
+		}
+	}
+
+	output.Reset()
+	if err = WriteHTML(&output, solver); err != nil {
+		t.Fatalf("\nfunction WriteHTML returning unexpected error \ngot: %v", err)
+	}
+	page := output.String()
+	if !strings.Contains(page, svg[:len(svg)-len("</svg>\n")]) || !strings.Contains(page, `"ant":1,"from":"S"`) ||
+		strings.Contains(page, "TURNS") {
+		t.Errorf("\nfunction WriteHTML not embedding the SVG rendering and turns"+
+			"\ngot: %v", page)
+	}
+}
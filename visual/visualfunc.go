@@ -0,0 +1,398 @@
+package visual
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"lem-in/routing"
+	"lem-in/sys"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Player animates the moves of a solution (the Turns recorded by a routing.Solver) on a character grid
+laid out from the Coords of the rooms of a sys.Farm, with tunnels drawn from the Links of the rooms.
+Turn is the number of turns currently shown (0 = all ants still in the start room).
+*/
+type Player struct {
+	Farm   *sys.Farm
+	Turns  [][]routing.Move
+	Turn   int
+	Width  int           // Width of the grid, in characters
+	Height int           // Height of the grid, in characters
+	Delay  time.Duration // Time between turns when playing
+}
+
+const (
+	ansiClear = "\x1b[H\x1b[2J"
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiStart = "\x1b[1;32m" // Bold green
+	ansiEnd   = "\x1b[1;31m" // Bold red
+	ansiAnt   = "\x1b[1;33m" // Bold yellow
+)
+
+/*
+cell is a single character of the grid, along with the ANSI style it is drawn with.
+*/
+type cell struct {
+	char  rune
+	style string
+}
+
+/*
+NewPlayer returns a Player for the input sys.Farm and recorded turns, showing the first turn on an
+80 x 20 character grid, with half a second between turns when playing.
+*/
+func NewPlayer(farm *sys.Farm, turns [][]routing.Move) *Player {
+	return &Player{
+		Farm:   farm,
+		Turns:  turns,
+		Width:  80,
+		Height: 20,
+		Delay:  500 * time.Millisecond,
+	}
+}
+
+/*
+Layout scales the Coords of the rooms of the Farm to positions ([column, row]) on the grid of the
+Player, leaving room for the room labels to the right of each position. Rooms are returned in the order
+of the Network of the Farm.
+*/
+func (player *Player) Layout() [][2]int {
+	positions := make([][2]int, len(player.Farm.Network))
+	if len(player.Farm.Network) == 0 {
+		return positions
+	}
+
+	nameWidth := 0
+	minX, maxX := player.Farm.Network[0].Coords[0], player.Farm.Network[0].Coords[0]
+	minY, maxY := player.Farm.Network[0].Coords[1], player.Farm.Network[0].Coords[1]
+	for _, room := range player.Farm.Network {
+		minX, maxX = minInt(minX, room.Coords[0]), maxInt(maxX, room.Coords[0])
+		minY, maxY = minInt(minY, room.Coords[1]), maxInt(maxY, room.Coords[1])
+		nameWidth = maxInt(nameWidth, len(room.Name))
+	}
+
+	// Labels are the room name followed by the ants in the room, e.g. "name[L12]" or "name(12)"
+	labelWidth := nameWidth + len(strconv.Itoa(player.Farm.TotalAntNbr)) + 3
+	columns := maxInt(player.Width-labelWidth, 1)
+	for i, room := range player.Farm.Network {
+		positions[i] = [2]int{scale(room.Coords[0], minX, maxX, columns), scale(room.Coords[1], minY, maxY, player.Height)}
+	}
+	return positions
+}
+
+/*
+scale maps the value from the range [min, max] to the range [0, size - 1].
+*/
+func scale(value, min, max, size int) int {
+	if max == min || size <= 1 {
+		return 0
+	}
+	return int(float64(value-min) / float64(max-min) * float64(size-1))
+}
+
+/*
+antStarts returns the name of the start room of every ant (by ant ID, index 0 unused): the room left by its
+first move in the turns, as ants may start in any of several start rooms, or the Start room of the Farm for
+ants which never move (or whose first move does not leave a start room).
+*/
+func antStarts(farm *sys.Farm, turns [][]routing.Move) []string {
+	isStart := make(map[string]bool, len(farm.Starts))
+	for _, start := range farm.Starts {
+		isStart[start.Name] = true
+	}
+	starts := make([]string, farm.TotalAntNbr+1)
+	moved := make([]bool, len(starts))
+	for _, moves := range turns {
+		for _, move := range moves {
+			if move.Ant > 0 && move.Ant < len(starts) && !moved[move.Ant] {
+				moved[move.Ant] = true
+				if isStart[move.From] {
+					starts[move.Ant] = move.From
+				}
+			}
+		}
+	}
+	for ant := 1; ant < len(starts); ant++ {
+		if starts[ant] == "" {
+			starts[ant] = farm.Start.Name
+		}
+	}
+	return starts
+}
+
+/*
+Positions replays the first "turn" Turns of the Player and returns the IDs of the ants in each room (by
+room name) after that turn. Ants which have not moved yet are in their start room (see antStarts),
+finished ants in their end room. A non-nil error is returned if a move does not start from the room the
+ant is in.
+*/
+func (player *Player) Positions(turn int) (map[string][]int, error) {
+	rooms := make(map[string][]int, len(player.Farm.Network))
+	antRoom := make(map[int]string, player.Farm.TotalAntNbr)
+	if player.Farm.Start == nil {
+		return rooms, errors.New("\nERROR: internal malfunction, the function \" Positions \" " +
+			"called while the Start room of the Farm is empty")
+	}
+	for ant, start := range antStarts(player.Farm, player.Turns) {
+		if ant > 0 {
+			rooms[start] = append(rooms[start], ant)
+			antRoom[ant] = start
+		}
+	}
+
+	for t := 0; t < turn && t < len(player.Turns); t++ {
+		for _, move := range player.Turns[t] {
+			if antRoom[move.Ant] != move.From {
+				return rooms, errors.New("\nERROR: internal malfunction, ant " + strconv.Itoa(move.Ant) +
+					" moved from room " + move.From + " in turn " + strconv.Itoa(t+1) + ", but is in room " +
+					antRoom[move.Ant])
+			}
+			rooms[move.From] = removeAnt(rooms[move.From], move.Ant)
+			rooms[move.To] = append(rooms[move.To], move.Ant)
+			antRoom[move.Ant] = move.To
+		}
+	}
+	return rooms, nil
+}
+
+/*
+removeAnt returns the input slice of ant IDs without the given ant.
+*/
+func removeAnt(ants []int, ant int) []int {
+	for i, id := range ants {
+		if id == ant {
+			return append(ants[:i], ants[i+1:]...)
+		}
+	}
+	return ants
+}
+
+/*
+Render draws the grid for the current Turn of the Player: tunnels as lines of " . " characters, rooms
+as their names (start in green, end in red), with the ants in each room written after its name
+(" [L1] ", or the number of ants for the start and end rooms), followed by the moves of the turn. ANSI
+escape codes are used for colours only, so the output works in any ANSI terminal.
+*/
+func (player *Player) Render() (string, error) {
+	ants, err := player.Positions(player.Turn)
+	if err != nil {
+		return "", err
+	}
+	positions := player.Layout()
+	grid := make([][]cell, player.Height)
+	for row := range grid {
+		grid[row] = make([]cell, player.Width)
+		for col := range grid[row] {
+			grid[row][col] = cell{char: ' '}
+		}
+	}
+
+	// Draw tunnels first, so that rooms are drawn over them
+	roomIndex := make(map[*sys.Room]int, len(player.Farm.Network))
+	for i := range player.Farm.Network {
+		roomIndex[&player.Farm.Network[i]] = i
+	}
+	for i, room := range player.Farm.Network {
+		for _, link := range room.Links {
+			if j, found := roomIndex[link]; found && j > i {
+				drawLine(grid, positions[i], positions[j])
+			}
+		}
+	}
+
+	for i, room := range player.Farm.Network {
+		style := ansiBold
+		label := room.Name
+		if room.Class == "start" || room.Class == "end" {
+			if room.Class == "start" {
+				style = ansiStart
+			} else {
+				style = ansiEnd
+			}
+			label += "(" + strconv.Itoa(len(ants[room.Name])) + ")"
+		} else if len(ants[room.Name]) > 0 {
+			style = ansiAnt
+			label += "[L" + strconv.Itoa(ants[room.Name][0]) + "]"
+		}
+		drawText(grid, positions[i], label, style)
+	}
+
+	var output strings.Builder
+	for _, row := range grid {
+		style := ""
+		for _, c := range row {
+			if c.style != style {
+				output.WriteString(ansiReset + c.style)
+				style = c.style
+			}
+			output.WriteRune(c.char)
+		}
+		output.WriteString(ansiReset + "\n")
+	}
+
+	output.WriteString("turn " + strconv.Itoa(player.Turn) + " / " + strconv.Itoa(len(player.Turns)) + "  ")
+	if player.Turn > 0 && player.Turn <= len(player.Turns) {
+		moves := make([]string, 0, len(player.Turns[player.Turn-1]))
+		for _, move := range player.Turns[player.Turn-1] {
+			moves = append(moves, "L"+strconv.Itoa(move.Ant)+"-"+move.To)
+		}
+		output.WriteString(strings.Join(moves, " "))
+	}
+	output.WriteString("\n")
+	return output.String(), nil
+}
+
+/*
+drawLine draws a tunnel between two grid positions, with " . " characters in dim style. Characters
+already on the grid are not overwritten.
+*/
+func drawLine(grid [][]cell, from, to [2]int) {
+	steps := maxInt(absInt(to[0]-from[0]), absInt(to[1]-from[1]))
+	for step := 0; step <= steps; step++ {
+		col, row := from[0], from[1]
+		if steps > 0 {
+			col += (to[0] - from[0]) * step / steps
+			row += (to[1] - from[1]) * step / steps
+		}
+		if row >= 0 && row < len(grid) && col >= 0 && col < len(grid[row]) && grid[row][col].char == ' ' {
+			grid[row][col] = cell{char: '.', style: ansiDim}
+		}
+	}
+}
+
+/*
+drawText writes the text to the grid, starting at the given position, in the given style. Text beyond
+the right edge of the grid is cut off.
+*/
+func drawText(grid [][]cell, position [2]int, text, style string) {
+	row := position[1]
+	if row < 0 || row >= len(grid) {
+		return
+	}
+	for i, char := range []rune(text) {
+		col := position[0] + i
+		if col >= 0 && col < len(grid[row]) {
+			grid[row][col] = cell{char: char, style: style}
+		}
+	}
+}
+
+/*
+Step moves the Player forwards (positive) or backwards (negative) by the given number of turns,
+staying within the first and last turn. It returns false if the Player could not move.
+*/
+func (player *Player) Step(turns int) bool {
+	turn := maxInt(0, minInt(len(player.Turns), player.Turn+turns))
+	moved := turn != player.Turn
+	player.Turn = turn
+	return moved
+}
+
+/*
+Play runs the interactive animation: the grid is redrawn on the io.Writer after every command read
+(one per line) from the io.Reader. Commands are:
+
+	<enter> / n   next turn         b   previous turn (rewind one)
+	p             play / pause      r   rewind to the first turn
+	g <turn>      go to turn        q   quit
+
+While playing, the Player steps forwards every Delay, until the last turn is reached or another
+command is entered. Play returns when "q" is entered or the input ends. A non-nil error is returned if
+rendering or writing fails.
+*/
+func (player *Player) Play(reader io.Reader, writer io.Writer) error {
+	commands := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(commands)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			select {
+			case commands <- strings.TrimSpace(scanner.Text()):
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	playing := false
+	for {
+		frame, err := player.Render()
+		if err != nil {
+			return err
+		}
+		status := "paused"
+		if playing {
+			status = "playing"
+		}
+		_, err = io.WriteString(writer, ansiClear+frame+status+
+			"  [enter/n] next  [b] back  [p] play/pause  [r] rewind  [g N] go to turn  [q] quit\n")
+		if err != nil {
+			return err
+		}
+
+		var command string
+		var open bool
+		wasPlaying := playing
+		if playing {
+			select {
+			case command, open = <-commands:
+				playing = false
+			case <-time.After(player.Delay):
+				playing = player.Step(1)
+				continue
+			}
+		} else {
+			command, open = <-commands
+		}
+		if !open || command == "q" {
+			return nil
+		}
+
+		fields := strings.Fields(command)
+		if len(fields) == 0 || fields[0] == "n" {
+			player.Step(1)
+		} else if fields[0] == "b" {
+			player.Step(-1)
+		} else if fields[0] == "p" {
+			playing = !wasPlaying && player.Turn < len(player.Turns)
+		} else if fields[0] == "r" {
+			player.Turn = 0
+		} else if fields[0] == "g" && len(fields) == 2 {
+			if turn, errAtoi := strconv.Atoi(fields[1]); errAtoi == nil {
+				player.Step(turn - player.Turn)
+			}
+		}
+	}
+}
+
+/*
+minInt, maxInt and absInt are integer helper functions.
+*/
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -0,0 +1,111 @@
+package visual
+
+import (
+	"bytes"
+	"context"
+	"lem-in/routing"
+	"lem-in/sys"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/*
+setupTestPlayer solves a small test farm (start-2-3-end, 2 ants) and returns a Player for its turns.
+*/
+func setupTestPlayer(t *testing.T) *Player {
+	farm, err := sys.Parse([]string{"2", "##start", "0 0 3", "2 2 5", "3 4 0", "##end", "1 8 3",
+		"0-2", "2-3", "3-1"})
+	if err != nil {
+		t.Fatalf("\nerror in parsing test farm \ngot: %v", err)
+	}
+	solver := routing.NewSolver(farm)
+	solver.EchoInput = false
+	solver.Output = &bytes.Buffer{}
+	solver.RecordTurns = true
+	if err = solver.Run(context.Background()); err != nil {
+		t.Fatalf("\nSolver returning unexpected error for test farm \ngot: %v", err)
+	}
+	return NewPlayer(farm, solver.Turns)
+}
+
+func TestLayout(t *testing.T) {
+	player := setupTestPlayer(t)
+	player.Width, player.Height = 20, 6
+	// Label width: 1 character name + 1 digit ant number + 3 = 5, leaving 15 columns
+	correct := [][2]int{{0, 3}, {3, 5}, {7, 0}, {14, 3}}
+
+	positions := player.Layout()
+	if !reflect.DeepEqual(positions, correct) {
+		t.Errorf("\nfunction Layout not returning expected positions"+
+			"\ngot: %v \nexpected: %v", positions, correct)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	player := setupTestPlayer(t)
+	correct := []map[string][]int{
+		{"0": {1, 2}},
+		{"0": {2}, "2": {1}},
+		{"0": {}, "2": {2}, "3": {1}},
+		{"0": {}, "2": {}, "3": {2}, "1": {1}},
+		{"0": {}, "2": {}, "3": {}, "1": {1, 2}},
+	}
+
+	for turn := range correct {
+		positions, err := player.Positions(turn)
+		if err != nil || !reflect.DeepEqual(positions, correct[turn]) {
+			t.Errorf("\nfunction Positions not returning expected positions for turn %v"+
+				"\ngot: %v \nexpected: %v \nerror: %v", turn, positions, correct[turn], err)
+		}
+	}
+
+	// Moves which do not start from the room of the ant
+	player.Turns = [][]routing.Move{{{Ant: 1, From: "3", To: "1"}}}
+	if _, err := player.Positions(1); err == nil {
+		t.Errorf("\nfunction Positions not returning error for invalid move")
+	}
+
+	// Ants starting in either of two start rooms (0 and 2), one of them never moving
+	farm, err := sys.Parse([]string{"3", "##multi", "##start", "0 0 3", "##start", "2 2 5", "3 4 0", "##end",
+		"1 8 3", "0-3", "2-3", "3-1"})
+	if err != nil {
+		t.Fatalf("\nerror in parsing test farm \ngot: %v", err)
+	}
+	player = NewPlayer(farm, [][]routing.Move{{{Ant: 1, From: "2", To: "3"}}, {{Ant: 1, From: "3", To: "1"},
+		{Ant: 2, From: "0", To: "3"}}})
+	positions, err := player.Positions(0)
+	correct0 := map[string][]int{"0": {2, 3}, "2": {1}}
+	if err != nil || !reflect.DeepEqual(positions, correct0) {
+		t.Errorf("\nfunction Positions not returning expected positions for several start rooms"+
+			"\ngot: %v \nexpected: %v \nerror: %v", positions, correct0, err)
+	}
+}
+
+func TestPlay(t *testing.T) {
+	player := setupTestPlayer(t)
+	var output bytes.Buffer
+
+	// Step forwards twice, back once, past the last turn, rewind, go to turn 3 and quit
+	err := player.Play(strings.NewReader("n\n\nb\ng 99\nr\ng 3\nq\nn\n"), &output)
+	if err != nil {
+		t.Fatalf("\nfunction Play returning unexpected error \ngot: %v", err)
+	}
+	frames := strings.Split(output.String(), ansiClear)[1:]
+	correct := []string{"turn 0 / 4", "turn 1 / 4", "turn 2 / 4", "turn 1 / 4", "turn 4 / 4",
+		"turn 0 / 4", "turn 3 / 4"}
+	if len(frames) != len(correct) {
+		t.Fatalf("\nfunction Play not drawing the expected number of frames"+
+			"\ngot: %v \nexpected: %v", len(frames), len(correct))
+	}
+	for i, frame := range frames {
+		if !strings.Contains(frame, correct[i]) {
+			t.Errorf("\nfunction Play not drawing the expected turn in frame %v"+
+				"\ngot: %v \nexpected: %v", i, frame, correct[i])
+		}
+	}
+	if !strings.Contains(frames[len(frames)-1], "3[L2]") || !strings.Contains(frames[len(frames)-1], "1(1)") {
+		t.Errorf("\nfunction Play not drawing the ants in their rooms"+
+			"\ngot: %v", frames[len(frames)-1])
+	}
+}